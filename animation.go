@@ -6,82 +6,158 @@ import (
 	"github.com/hajimehoshi/ebiten/v2"
 )
 
+type AnimationDirection string
+
+const (
+	AnimationForward         AnimationDirection = "forward"
+	AnimationReverse         AnimationDirection = "reverse"
+	AnimationPingPong        AnimationDirection = "pingpong"
+	AnimationPingPongReverse AnimationDirection = "pingpong_reverse"
+
+	defaultFrameDuration = 100 * time.Millisecond
+)
+
+type AnimationFrame struct {
+	Image    *ebiten.Image
+	Duration time.Duration
+	Event    string
+}
+
+type AnimationClip struct {
+	Name      string
+	Frames    []AnimationFrame
+	Loop      bool
+	Direction AnimationDirection
+	Speed     float64
+}
+
+type EventAnimationFrameData struct {
+	Clip  string
+	Frame int
+	Event string
+}
+
+type EventAnimationStateData struct {
+	From string
+	To   string
+}
+
+func NewAnimationClip(name string, frameDuration time.Duration, loop bool, frames ...*ebiten.Image) *AnimationClip {
+	clip := &AnimationClip{
+		Name:   name,
+		Loop:   loop,
+		Frames: make([]AnimationFrame, len(frames)),
+	}
+	for i, frame := range frames {
+		clip.Frames[i] = AnimationFrame{Image: frame, Duration: frameDuration}
+	}
+	return clip
+}
+
+func (c *AnimationClip) SetFrameEvent(index int, event string) *AnimationClip {
+	if index >= 0 && index < len(c.Frames) {
+		c.Frames[index].Event = event
+	}
+	return c
+}
+
+func (c *AnimationClip) Duration() time.Duration {
+	var total time.Duration
+	for _, frame := range c.Frames {
+		total += frameDuration(frame)
+	}
+	return total
+}
+
+func frameDuration(frame AnimationFrame) time.Duration {
+	if frame.Duration <= 0 {
+		return defaultFrameDuration
+	}
+	return frame.Duration
+}
+
 type Animation struct {
-	target       *Shape
-	frames       []*ebiten.Image
-	currentFrame int
-	speed        time.Duration
-	loop         bool
-	isPlaying    bool
-	ticker       *time.Ticker
-	stopCh       chan bool
-	onFinish     func(*Shape)
+	Clip  *AnimationClip
+	Speed float64
+
+	target     *Shape
+	frame      int
+	step       int
+	elapsed    float64
+	isPlaying  bool
+	finished   bool
+	registered bool
+	onFinish   func(*Shape)
+	onFrame    map[int][]func(*Shape)
 }
 
 func NewAnimation(target *Shape, speed time.Duration, loop bool, frames ...*ebiten.Image) *Animation {
 	if speed == 0 {
-		speed = 100 * time.Millisecond
+		speed = defaultFrameDuration
 	}
 
+	return NewClipAnimation(target, NewAnimationClip("", speed, loop, frames...))
+}
+
+func NewClipAnimation(target *Shape, clip *AnimationClip) *Animation {
 	anim := &Animation{
-		target:       target,
-		frames:       frames,
-		currentFrame: 0,
-		speed:        speed,
-		loop:         loop,
-		isPlaying:    false,
-		stopCh:       make(chan bool),
-		onFinish:     func(*Shape) {},
+		Clip:     clip,
+		Speed:    1,
+		target:   target,
+		onFinish: func(*Shape) {},
 	}
-
+	anim.rewind()
 	return anim
 }
 
+func (a *Animation) rewind() {
+	a.elapsed = 0
+	a.finished = false
+	a.step = 1
+	a.frame = 0
+
+	switch a.Clip.Direction {
+	case AnimationReverse, AnimationPingPongReverse:
+		a.step = -1
+		a.frame = len(a.Clip.Frames) - 1
+	}
+}
+
 func (a *Animation) Start() *Animation {
+	a.play()
+
+	if a.target != nil && !a.registered {
+		a.registered = true
+		a.target.animations = append(a.target.animations, a)
+	}
+	return a
+}
+
+func (a *Animation) play() {
 	if a.isPlaying {
-		return a
+		return
+	}
+	if a.finished {
+		a.rewind()
 	}
 
 	a.isPlaying = true
-	a.ticker = time.NewTicker(a.speed)
-
-	go func() {
-		for {
-			select {
-			case <-a.ticker.C:
-				if !a.isPlaying {
-					continue
-				}
-
-				a.currentFrame++
-				if a.currentFrame >= len(a.frames) {
-					a.currentFrame = 0
-					if !a.loop {
-						a.isPlaying = false
-						a.onFinish(a.target)
-						return
-					}
-				}
-
-				if a.currentFrame < len(a.frames) {
-					a.target.Image = a.frames[a.currentFrame]
-				}
-
-			case <-a.stopCh:
-				return
-			}
-		}
-	}()
+	a.enterFrame()
+}
 
+func (a *Animation) Stop() *Animation {
+	a.isPlaying = false
 	return a
 }
 
-func (a *Animation) Stop() *Animation {
+func (a *Animation) Restart() *Animation {
 	a.isPlaying = false
-	if a.ticker != nil {
-		a.ticker.Stop()
-	}
-	a.stopCh <- true
+	a.rewind()
+	return a.Start()
+}
+
+func (a *Animation) SetSpeed(speed float64) *Animation {
+	a.Speed = speed
 	return a
 }
 
@@ -90,6 +166,281 @@ func (a *Animation) OnFinish(callback func(*Shape)) *Animation {
 	return a
 }
 
+func (a *Animation) OnFrame(index int, callback func(*Shape)) *Animation {
+	if a.onFrame == nil {
+		a.onFrame = make(map[int][]func(*Shape))
+	}
+	a.onFrame[index] = append(a.onFrame[index], callback)
+	return a
+}
+
 func (a *Animation) IsPlaying() bool {
 	return a.isPlaying
 }
+
+func (a *Animation) Finished() bool {
+	return a.finished
+}
+
+func (a *Animation) Frame() int {
+	return a.frame
+}
+
+func (a *Animation) Image() *ebiten.Image {
+	if a.frame < 0 || a.frame >= len(a.Clip.Frames) {
+		return nil
+	}
+	return a.Clip.Frames[a.frame].Image
+}
+
+func (a *Animation) Update(delta float64) {
+	if !a.isPlaying || len(a.Clip.Frames) == 0 {
+		return
+	}
+
+	speed := a.Speed
+	if a.Clip.Speed > 0 {
+		speed *= a.Clip.Speed
+	}
+	if speed <= 0 {
+		return
+	}
+
+	a.elapsed += delta * speed
+	for a.isPlaying {
+		duration := frameDuration(a.Clip.Frames[a.frame]).Seconds()
+		if a.elapsed < duration {
+			break
+		}
+		a.elapsed -= duration
+		a.advance()
+	}
+}
+
+func (a *Animation) advance() {
+	count := len(a.Clip.Frames)
+	next := a.frame + a.step
+
+	if next < 0 || next >= count {
+		pingPong := a.Clip.Direction == AnimationPingPong || a.Clip.Direction == AnimationPingPongReverse
+		returning := (a.Clip.Direction == AnimationPingPong && a.step < 0) ||
+			(a.Clip.Direction == AnimationPingPongReverse && a.step > 0)
+
+		switch {
+		case pingPong && !returning:
+			a.step = -a.step
+		case !a.Clip.Loop:
+			a.finish()
+			return
+		case pingPong:
+			a.step = -a.step
+		default:
+			if a.step > 0 {
+				next = 0
+			} else {
+				next = count - 1
+			}
+		}
+
+		if pingPong {
+			next = a.frame + a.step
+			if count == 1 {
+				next = a.frame
+			}
+		}
+	}
+
+	a.frame = next
+	a.enterFrame()
+}
+
+func (a *Animation) enterFrame() {
+	if a.frame < 0 || a.frame >= len(a.Clip.Frames) {
+		return
+	}
+	frame := a.Clip.Frames[a.frame]
+
+	if a.target != nil && frame.Image != nil {
+		a.target.Image = frame.Image
+	}
+	if a.target != nil && a.target.EventEmitter != nil {
+		a.target.Emit(EventAnimationFrame, EventAnimationFrameData{
+			Clip:  a.Clip.Name,
+			Frame: a.frame,
+			Event: frame.Event,
+		})
+	}
+
+	for _, callback := range a.onFrame[a.frame] {
+		callback(a.target)
+	}
+}
+
+func (a *Animation) finish() {
+	a.isPlaying = false
+	a.finished = true
+	a.elapsed = 0
+
+	if a.onFinish != nil {
+		a.onFinish(a.target)
+	}
+	if a.target != nil && a.target.EventEmitter != nil {
+		a.target.Emit(EventAnimationFinish, EventAnimationFrameData{Clip: a.Clip.Name, Frame: a.frame})
+	}
+}
+
+func (s *Shape) updateAnimations(delta float64) {
+	if s.Animator != nil {
+		s.Animator.Update(delta)
+	}
+
+	if len(s.animations) == 0 {
+		return
+	}
+
+	active := s.animations[:0]
+	for _, anim := range s.animations {
+		anim.Update(delta)
+		if anim.isPlaying {
+			active = append(active, anim)
+		} else {
+			anim.registered = false
+		}
+	}
+	for i := len(active); i < len(s.animations); i++ {
+		s.animations[i] = nil
+	}
+	s.animations = active
+}
+
+type animatorTransition struct {
+	from      string
+	to        string
+	condition func() bool
+	onFinish  bool
+}
+
+type Animator struct {
+	target      *Shape
+	clips       map[string]*AnimationClip
+	transitions []animatorTransition
+	state       string
+	animation   *Animation
+	onChange    func(from, to string)
+	onFrame     map[string]map[int][]func(*Shape)
+}
+
+func NewAnimator(target *Shape) *Animator {
+	animator := &Animator{
+		target: target,
+		clips:  make(map[string]*AnimationClip),
+	}
+	if target != nil {
+		target.Animator = animator
+	}
+	return animator
+}
+
+func (an *Animator) AddClip(clip *AnimationClip) *Animator {
+	an.clips[clip.Name] = clip
+	return an
+}
+
+func (an *Animator) AddState(name string, clip *AnimationClip) *Animator {
+	an.clips[name] = clip
+	return an
+}
+
+func (an *Animator) AddTransition(from, to string, condition func() bool) *Animator {
+	an.transitions = append(an.transitions, animatorTransition{from: from, to: to, condition: condition})
+	return an
+}
+
+func (an *Animator) AddExitTransition(from, to string) *Animator {
+	an.transitions = append(an.transitions, animatorTransition{from: from, to: to, onFinish: true})
+	return an
+}
+
+func (an *Animator) OnStateChange(callback func(from, to string)) *Animator {
+	an.onChange = callback
+	return an
+}
+
+func (an *Animator) OnFrame(state string, index int, callback func(*Shape)) *Animator {
+	if an.onFrame == nil {
+		an.onFrame = make(map[string]map[int][]func(*Shape))
+	}
+	if an.onFrame[state] == nil {
+		an.onFrame[state] = make(map[int][]func(*Shape))
+	}
+	an.onFrame[state][index] = append(an.onFrame[state][index], callback)
+
+	if an.state == state && an.animation != nil {
+		an.animation.OnFrame(index, callback)
+	}
+	return an
+}
+
+func (an *Animator) State() string {
+	return an.state
+}
+
+func (an *Animator) Animation() *Animation {
+	return an.animation
+}
+
+func (an *Animator) Play(state string) *Animator {
+	if state == an.state && an.animation != nil && an.animation.isPlaying {
+		return an
+	}
+
+	clip, ok := an.clips[state]
+	if !ok {
+		return an
+	}
+
+	from := an.state
+	an.state = state
+	an.animation = NewClipAnimation(an.target, clip)
+	for index, callbacks := range an.onFrame[state] {
+		for _, callback := range callbacks {
+			an.animation.OnFrame(index, callback)
+		}
+	}
+	an.animation.play()
+
+	if from != state {
+		if an.onChange != nil {
+			an.onChange(from, state)
+		}
+		if an.target != nil && an.target.EventEmitter != nil {
+			an.target.Emit(EventAnimationState, EventAnimationStateData{From: from, To: state})
+		}
+	}
+	return an
+}
+
+func (an *Animator) Update(delta float64) {
+	for _, t := range an.transitions {
+		if t.from != an.state && t.from != "*" {
+			continue
+		}
+		if t.to == an.state {
+			continue
+		}
+		if t.onFinish {
+			if an.animation == nil || !an.animation.finished {
+				continue
+			}
+		} else if t.condition == nil || !t.condition() {
+			continue
+		}
+
+		an.Play(t.to)
+		break
+	}
+
+	if an.animation != nil {
+		an.animation.Update(delta)
+	}
+}
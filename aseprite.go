@@ -0,0 +1,248 @@
+package life
+
+import (
+	"bytes"
+	"embed"
+	"encoding/json"
+	"fmt"
+	"image"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	"github.com/hajimehoshi/ebiten/v2"
+)
+
+type asepriteFile struct {
+	Frames json.RawMessage `json:"frames"`
+	Meta   struct {
+		Image     string `json:"image"`
+		FrameTags []struct {
+			Name      string `json:"name"`
+			From      int    `json:"from"`
+			To        int    `json:"to"`
+			Direction string `json:"direction"`
+			Repeat    string `json:"repeat"`
+		} `json:"frameTags"`
+		Slices []struct {
+			Name string `json:"name"`
+			Keys []struct {
+				Frame  int         `json:"frame"`
+				Bounds packedRect  `json:"bounds"`
+				Center *packedRect `json:"center"`
+				Pivot  *struct {
+					X float64 `json:"x"`
+					Y float64 `json:"y"`
+				} `json:"pivot"`
+			} `json:"keys"`
+		} `json:"slices"`
+	} `json:"meta"`
+}
+
+type AsepriteSliceKey struct {
+	Frame    int
+	Bounds   image.Rectangle
+	Center   image.Rectangle
+	Pivot    Vector2
+	HasPivot bool
+}
+
+type AsepriteSlice struct {
+	Name string
+	Keys []AsepriteSliceKey
+}
+
+type AsepriteSheet struct {
+	Image      *ebiten.Image
+	Frames     []AnimationFrame
+	FrameNames []string
+	Clips      map[string]*AnimationClip
+	Tags       []string
+	Slices     map[string]*AsepriteSlice
+}
+
+func LoadAseprite(jsonPath string) (*AsepriteSheet, error) {
+	dir := filepath.Dir(jsonPath)
+	return loadAseprite(jsonPath, os.ReadFile, func(file string) string {
+		return filepath.Join(dir, file)
+	})
+}
+
+func LoadAsepriteFromFS(fs embed.FS, jsonPath string) (*AsepriteSheet, error) {
+	dir := path.Dir(jsonPath)
+	return loadAseprite(jsonPath, fs.ReadFile, func(file string) string {
+		return path.Join(dir, file)
+	})
+}
+
+func loadAseprite(jsonPath string, readFile func(string) ([]byte, error), resolve func(string) string) (*AsepriteSheet, error) {
+	data, err := readFile(jsonPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read aseprite sheet %s: %w", jsonPath, err)
+	}
+
+	var file asepriteFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("failed to parse aseprite sheet %s: %w", jsonPath, err)
+	}
+
+	frames, err := decodePackedFrames(file.Frames)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse aseprite frames in %s: %w", jsonPath, err)
+	}
+
+	imageData, err := readFile(resolve(file.Meta.Image))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read aseprite image %s: %w", file.Meta.Image, err)
+	}
+	img, _, err := image.Decode(bytes.NewReader(imageData))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode aseprite image %s: %w", file.Meta.Image, err)
+	}
+
+	sheet := &AsepriteSheet{
+		Image:  ebiten.NewImageFromImage(img),
+		Clips:  make(map[string]*AnimationClip),
+		Slices: make(map[string]*AsepriteSlice),
+	}
+
+	for _, frame := range frames {
+		sheet.Frames = append(sheet.Frames, AnimationFrame{
+			Image:    sheet.frameImage(frame),
+			Duration: time.Duration(frame.Duration) * time.Millisecond,
+		})
+		sheet.FrameNames = append(sheet.FrameNames, frame.Filename)
+	}
+
+	for _, tag := range file.Meta.FrameTags {
+		if tag.From < 0 || tag.To >= len(sheet.Frames) || tag.From > tag.To {
+			return nil, fmt.Errorf("aseprite tag %s in %s has invalid frame range %d-%d", tag.Name, jsonPath, tag.From, tag.To)
+		}
+
+		clip := &AnimationClip{
+			Name:      tag.Name,
+			Loop:      true,
+			Direction: asepriteDirection(tag.Direction),
+		}
+		frames := sheet.Frames[tag.From : tag.To+1]
+
+		repeat, _ := strconv.Atoi(tag.Repeat)
+		if repeat > 0 {
+			clip.Loop = false
+			if clip.Direction == AnimationForward || clip.Direction == AnimationReverse {
+				for i := 1; i < repeat; i++ {
+					clip.Frames = append(clip.Frames, frames...)
+				}
+			}
+		}
+		clip.Frames = append(clip.Frames, frames...)
+
+		sheet.Clips[tag.Name] = clip
+		sheet.Tags = append(sheet.Tags, tag.Name)
+	}
+
+	for _, s := range file.Meta.Slices {
+		slice := &AsepriteSlice{Name: s.Name}
+		for _, key := range s.Keys {
+			sliceKey := AsepriteSliceKey{
+				Frame:  key.Frame,
+				Bounds: key.Bounds.rectangle(),
+			}
+			if key.Center != nil {
+				sliceKey.Center = key.Center.rectangle()
+			}
+			if key.Pivot != nil {
+				sliceKey.Pivot = Vector2{X: key.Pivot.X, Y: key.Pivot.Y}
+				sliceKey.HasPivot = true
+			}
+			slice.Keys = append(slice.Keys, sliceKey)
+		}
+		sheet.Slices[s.Name] = slice
+	}
+
+	return sheet, nil
+}
+
+func asepriteDirection(direction string) AnimationDirection {
+	switch direction {
+	case "reverse":
+		return AnimationReverse
+	case "pingpong":
+		return AnimationPingPong
+	case "pingpong_reverse":
+		return AnimationPingPongReverse
+	}
+	return AnimationForward
+}
+
+func (a *AsepriteSheet) frameImage(frame packedFrame) *ebiten.Image {
+	sub := a.Image.SubImage(frame.Frame.rectangle()).(*ebiten.Image)
+	if !frame.Trimmed || frame.SourceSize.W <= 0 || frame.SourceSize.H <= 0 {
+		return sub
+	}
+
+	canvas := ebiten.NewImage(frame.SourceSize.W, frame.SourceSize.H)
+	op := &ebiten.DrawImageOptions{}
+	op.GeoM.Translate(float64(frame.SpriteSourceSize.X), float64(frame.SpriteSourceSize.Y))
+	canvas.DrawImage(sub, op)
+	return canvas
+}
+
+func (a *AsepriteSheet) Clip(tag string) *AnimationClip {
+	return a.Clips[tag]
+}
+
+func (a *AsepriteSheet) AllFrames(loop bool) *AnimationClip {
+	return &AnimationClip{
+		Loop:   loop,
+		Frames: append([]AnimationFrame(nil), a.Frames...),
+	}
+}
+
+func (a *AsepriteSheet) Animation(target *Shape, tag string) *Animation {
+	clip := a.Clips[tag]
+	if clip == nil {
+		return nil
+	}
+	return NewClipAnimation(target, clip)
+}
+
+func (a *AsepriteSheet) Animator(target *Shape) *Animator {
+	animator := NewAnimator(target)
+	for _, tag := range a.Tags {
+		animator.AddClip(a.Clips[tag])
+	}
+	return animator
+}
+
+func (a *AsepriteSheet) Slice(name string, frame int) (AsepriteSliceKey, bool) {
+	slice := a.Slices[name]
+	if slice == nil || len(slice.Keys) == 0 {
+		return AsepriteSliceKey{}, false
+	}
+
+	key := slice.Keys[0]
+	for _, k := range slice.Keys {
+		if k.Frame > frame {
+			break
+		}
+		key = k
+	}
+	return key, true
+}
+
+func (a *AsepriteSheet) NineSlice(name string) *NineSlice {
+	key, ok := a.Slice(name, 0)
+	if !ok || key.Center.Empty() {
+		return nil
+	}
+
+	return &NineSlice{
+		Left:   float64(key.Center.Min.X),
+		Top:    float64(key.Center.Min.Y),
+		Right:  float64(key.Bounds.Dx() - key.Center.Max.X),
+		Bottom: float64(key.Bounds.Dy() - key.Center.Max.Y),
+	}
+}
@@ -2,10 +2,14 @@ package life
 
 import (
 	"embed"
+	"fmt"
 	"image"
 	_ "image/jpeg"
 	_ "image/png"
 	"os"
+	"strconv"
+	"strings"
+	"sync"
 
 	"github.com/hajimehoshi/ebiten/v2"
 )
@@ -75,6 +79,7 @@ type SpriteSheet struct {
 	FrameWidth  int
 	FrameHeight int
 	Frames      []*ebiten.Image
+	FrameNames  []string
 }
 
 func NewSpriteSheet(imagePath string, frameWidth, frameHeight int) (*SpriteSheet, error) {
@@ -92,6 +97,8 @@ func NewSpriteSheet(imagePath string, frameWidth, frameHeight int) (*SpriteSheet
 	bounds := img.Bounds()
 	cols := bounds.Dx() / frameWidth
 	rows := bounds.Dy() / frameHeight
+	rowDigits := len(strconv.Itoa(max(rows-1, 0)))
+	colDigits := len(strconv.Itoa(max(cols-1, 0)))
 
 	for row := 0; row < rows; row++ {
 		for col := 0; col < cols; col++ {
@@ -100,6 +107,7 @@ func NewSpriteSheet(imagePath string, frameWidth, frameHeight int) (*SpriteSheet
 
 			frame := img.SubImage(image.Rect(x, y, x+frameWidth, y+frameHeight)).(*ebiten.Image)
 			sheet.Frames = append(sheet.Frames, frame)
+			sheet.FrameNames = append(sheet.FrameNames, fmt.Sprintf("%0*d/%0*d", rowDigits, row, colDigits, col))
 		}
 	}
 
@@ -119,3 +127,39 @@ func (s *SpriteSheet) GetFrames(start, end int) []*ebiten.Image {
 	}
 	return s.Frames[start : end+1]
 }
+
+// GetFramesWithPrefix matches against FrameNames. Grid sheets from NewSpriteSheet
+// name their frames "row/col" with both zero-padded to the widest index, so on a
+// sheet with 12 rows the prefix "02" selects the third row and nothing else.
+func (s *SpriteSheet) GetFramesWithPrefix(prefix string) []*ebiten.Image {
+	var frames []*ebiten.Image
+	for i, name := range s.FrameNames {
+		if i < len(s.Frames) && strings.HasPrefix(name, prefix) {
+			frames = append(frames, s.Frames[i])
+		}
+	}
+	return frames
+}
+
+var (
+	spriteMutex sync.RWMutex
+	sprites     = map[string]*ebiten.Image{}
+)
+
+func RegisterSprite(name string, img *ebiten.Image) {
+	spriteMutex.Lock()
+	sprites[name] = img
+	spriteMutex.Unlock()
+}
+
+func UnregisterSprite(name string) {
+	spriteMutex.Lock()
+	delete(sprites, name)
+	spriteMutex.Unlock()
+}
+
+func GetSprite(name string) *ebiten.Image {
+	spriteMutex.RLock()
+	defer spriteMutex.RUnlock()
+	return sprites[name]
+}
@@ -0,0 +1,475 @@
+package life
+
+import (
+	"bufio"
+	"bytes"
+	"embed"
+	"encoding/json"
+	"fmt"
+	"image"
+	"math"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/hajimehoshi/ebiten/v2"
+)
+
+type packedRect struct {
+	X int `json:"x"`
+	Y int `json:"y"`
+	W int `json:"w"`
+	H int `json:"h"`
+}
+
+func (r packedRect) rectangle() image.Rectangle {
+	return image.Rect(r.X, r.Y, r.X+r.W, r.Y+r.H)
+}
+
+type packedFrame struct {
+	Filename         string     `json:"filename"`
+	Frame            packedRect `json:"frame"`
+	Rotated          bool       `json:"rotated"`
+	Trimmed          bool       `json:"trimmed"`
+	SpriteSourceSize packedRect `json:"spriteSourceSize"`
+	SourceSize       struct {
+		W int `json:"w"`
+		H int `json:"h"`
+	} `json:"sourceSize"`
+	Pivot *struct {
+		X float64 `json:"x"`
+		Y float64 `json:"y"`
+	} `json:"pivot"`
+	Duration int `json:"duration"`
+}
+
+func decodePackedFrames(raw json.RawMessage) ([]packedFrame, error) {
+	raw = bytes.TrimSpace(raw)
+	if len(raw) == 0 {
+		return nil, nil
+	}
+
+	if raw[0] == '[' {
+		var frames []packedFrame
+		err := json.Unmarshal(raw, &frames)
+		return frames, err
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(raw))
+	if _, err := decoder.Token(); err != nil {
+		return nil, err
+	}
+
+	var frames []packedFrame
+	for decoder.More() {
+		token, err := decoder.Token()
+		if err != nil {
+			return nil, err
+		}
+		name, _ := token.(string)
+
+		var frame packedFrame
+		if err := decoder.Decode(&frame); err != nil {
+			return nil, err
+		}
+		if frame.Filename == "" {
+			frame.Filename = name
+		}
+		frames = append(frames, frame)
+	}
+	return frames, nil
+}
+
+type AtlasRegion struct {
+	Name         string
+	Index        int
+	Page         int
+	Image        *ebiten.Image
+	Bounds       image.Rectangle
+	Rotated      bool
+	Trimmed      bool
+	Offset       image.Point
+	OriginalSize image.Point
+	Pivot        Vector2
+}
+
+type TextureAtlas struct {
+	Pages   []*ebiten.Image
+	Regions []*AtlasRegion
+
+	byName map[string]*AtlasRegion
+}
+
+func newTextureAtlas() *TextureAtlas {
+	return &TextureAtlas{byName: make(map[string]*AtlasRegion)}
+}
+
+func LoadAtlas(atlasPath string) (*TextureAtlas, error) {
+	if strings.EqualFold(filepath.Ext(atlasPath), ".json") {
+		return LoadTexturePackerAtlas(atlasPath)
+	}
+	return LoadLibGDXAtlas(atlasPath)
+}
+
+func LoadAtlasFromFS(fs embed.FS, atlasPath string) (*TextureAtlas, error) {
+	if strings.EqualFold(path.Ext(atlasPath), ".json") {
+		return LoadTexturePackerAtlasFromFS(fs, atlasPath)
+	}
+	return LoadLibGDXAtlasFromFS(fs, atlasPath)
+}
+
+func LoadTexturePackerAtlas(jsonPath string) (*TextureAtlas, error) {
+	dir := filepath.Dir(jsonPath)
+	return loadTexturePackerAtlas(jsonPath, os.ReadFile, func(file string) string {
+		return filepath.Join(dir, file)
+	})
+}
+
+func LoadTexturePackerAtlasFromFS(fs embed.FS, jsonPath string) (*TextureAtlas, error) {
+	dir := path.Dir(jsonPath)
+	return loadTexturePackerAtlas(jsonPath, fs.ReadFile, func(file string) string {
+		return path.Join(dir, file)
+	})
+}
+
+func LoadLibGDXAtlas(atlasPath string) (*TextureAtlas, error) {
+	dir := filepath.Dir(atlasPath)
+	return loadLibGDXAtlas(atlasPath, os.ReadFile, func(file string) string {
+		return filepath.Join(dir, file)
+	})
+}
+
+func LoadLibGDXAtlasFromFS(fs embed.FS, atlasPath string) (*TextureAtlas, error) {
+	dir := path.Dir(atlasPath)
+	return loadLibGDXAtlas(atlasPath, fs.ReadFile, func(file string) string {
+		return path.Join(dir, file)
+	})
+}
+
+func loadPage(file string, readFile func(string) ([]byte, error)) (*ebiten.Image, error) {
+	data, err := readFile(file)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read atlas page %s: %w", file, err)
+	}
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode atlas page %s: %w", file, err)
+	}
+	return ebiten.NewImageFromImage(img), nil
+}
+
+func loadTexturePackerAtlas(jsonPath string, readFile func(string) ([]byte, error), resolve func(string) string) (*TextureAtlas, error) {
+	data, err := readFile(jsonPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read atlas %s: %w", jsonPath, err)
+	}
+
+	var file struct {
+		Frames json.RawMessage `json:"frames"`
+		Meta   struct {
+			Image string `json:"image"`
+		} `json:"meta"`
+	}
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("failed to parse atlas %s: %w", jsonPath, err)
+	}
+
+	frames, err := decodePackedFrames(file.Frames)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse atlas frames in %s: %w", jsonPath, err)
+	}
+
+	page, err := loadPage(resolve(file.Meta.Image), readFile)
+	if err != nil {
+		return nil, err
+	}
+
+	atlas := newTextureAtlas()
+	atlas.Pages = append(atlas.Pages, page)
+
+	for _, frame := range frames {
+		region := &AtlasRegion{
+			Name:         frame.Filename,
+			Index:        -1,
+			Rotated:      frame.Rotated,
+			Trimmed:      frame.Trimmed,
+			Offset:       image.Pt(frame.SpriteSourceSize.X, frame.SpriteSourceSize.Y),
+			OriginalSize: image.Pt(frame.SourceSize.W, frame.SourceSize.H),
+		}
+
+		width, height := frame.Frame.W, frame.Frame.H
+		if frame.Rotated {
+			width, height = height, width
+		}
+		region.Bounds = image.Rect(frame.Frame.X, frame.Frame.Y, frame.Frame.X+width, frame.Frame.Y+height)
+
+		if region.OriginalSize == (image.Point{}) {
+			region.OriginalSize = image.Pt(frame.Frame.W, frame.Frame.H)
+		}
+		if frame.Pivot != nil {
+			region.Pivot = Vector2{X: frame.Pivot.X, Y: frame.Pivot.Y}
+		}
+
+		region.Image = restoreRegion(page, region, -90)
+		atlas.add(region)
+	}
+
+	return atlas, nil
+}
+
+func loadLibGDXAtlas(atlasPath string, readFile func(string) ([]byte, error), resolve func(string) string) (*TextureAtlas, error) {
+	data, err := readFile(atlasPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read atlas %s: %w", atlasPath, err)
+	}
+
+	atlas := newTextureAtlas()
+	var page *ebiten.Image
+	var region *AtlasRegion
+	fields := map[string][]int{}
+	expectPage := true
+
+	finish := func() {
+		if region == nil {
+			return
+		}
+		defer func() {
+			region = nil
+			fields = map[string][]int{}
+		}()
+		if page == nil {
+			return
+		}
+
+		bounds := fields["bounds"]
+		if len(bounds) != 4 {
+			bounds = append(fields["xy"], fields["size"]...)
+		}
+		width, height := 0, 0
+		if len(bounds) == 4 {
+			width, height = bounds[2], bounds[3]
+			packedW, packedH := width, height
+			if region.Rotated {
+				packedW, packedH = height, width
+			}
+			region.Bounds = image.Rect(bounds[0], bounds[1], bounds[0]+packedW, bounds[1]+packedH)
+		}
+
+		region.OriginalSize = image.Pt(width, height)
+		offsetX, offsetY := 0, 0
+		if v := fields["offsets"]; len(v) == 4 {
+			offsetX, offsetY = v[0], v[1]
+			region.OriginalSize = image.Pt(v[2], v[3])
+		} else {
+			if v := fields["orig"]; len(v) == 2 {
+				region.OriginalSize = image.Pt(v[0], v[1])
+			}
+			if v := fields["offset"]; len(v) == 2 {
+				offsetX, offsetY = v[0], v[1]
+			}
+		}
+
+		region.Offset = image.Pt(offsetX, region.OriginalSize.Y-height-offsetY)
+		region.Trimmed = region.OriginalSize != image.Pt(width, height)
+		region.Image = restoreRegion(page, region, 90)
+		atlas.add(region)
+	}
+
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			finish()
+			expectPage = true
+			continue
+		}
+
+		key, value, isField := strings.Cut(line, ":")
+		if !isField {
+			finish()
+			if expectPage {
+				page, err = loadPage(resolve(line), readFile)
+				if err != nil {
+					return nil, err
+				}
+				atlas.Pages = append(atlas.Pages, page)
+				expectPage = false
+				continue
+			}
+			region = &AtlasRegion{Name: line, Index: -1, Page: len(atlas.Pages) - 1}
+			continue
+		}
+
+		if region == nil {
+			continue
+		}
+
+		key = strings.TrimSpace(key)
+		value = strings.TrimSpace(value)
+		switch key {
+		case "rotate":
+			region.Rotated = value == "true" || value == "90"
+		case "index":
+			region.Index, _ = strconv.Atoi(value)
+		default:
+			fields[key] = parseInts(value)
+		}
+	}
+	finish()
+
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to parse atlas %s: %w", atlasPath, err)
+	}
+	if page == nil {
+		return nil, fmt.Errorf("atlas %s has no pages", atlasPath)
+	}
+
+	return atlas, nil
+}
+
+func parseInts(value string) []int {
+	parts := strings.Split(value, ",")
+	numbers := make([]int, 0, len(parts))
+	for _, part := range parts {
+		n, err := strconv.Atoi(strings.TrimSpace(part))
+		if err != nil {
+			return nil
+		}
+		numbers = append(numbers, n)
+	}
+	return numbers
+}
+
+func restoreRegion(page *ebiten.Image, region *AtlasRegion, unrotate float64) *ebiten.Image {
+	sub := page.SubImage(region.Bounds).(*ebiten.Image)
+	if !region.Rotated && !region.Trimmed && region.Offset == (image.Point{}) {
+		return sub
+	}
+
+	width, height := region.Bounds.Dx(), region.Bounds.Dy()
+	if region.Rotated {
+		width, height = height, width
+	}
+	original := region.OriginalSize
+	if original.X < width || original.Y < height {
+		original = image.Pt(max(original.X, width), max(original.Y, height))
+	}
+
+	canvas := ebiten.NewImage(original.X, original.Y)
+	op := &ebiten.DrawImageOptions{}
+	if region.Rotated {
+		op.GeoM.Translate(-float64(region.Bounds.Dx())/2, -float64(region.Bounds.Dy())/2)
+		op.GeoM.Rotate(unrotate * Deg)
+		op.GeoM.Translate(float64(width)/2, float64(height)/2)
+	}
+	op.GeoM.Translate(float64(region.Offset.X), float64(region.Offset.Y))
+	canvas.DrawImage(sub, op)
+	return canvas
+}
+
+func (a *TextureAtlas) add(region *AtlasRegion) {
+	a.Regions = append(a.Regions, region)
+
+	key := region.Name
+	if region.Index >= 0 {
+		key = region.Name + "_" + strconv.Itoa(region.Index)
+		if existing, ok := a.byName[region.Name]; !ok || existing.Index > region.Index {
+			a.byName[region.Name] = region
+		}
+	}
+	a.byName[key] = region
+
+	if trimmed := strings.TrimSuffix(key, path.Ext(key)); trimmed != key {
+		if _, ok := a.byName[trimmed]; !ok {
+			a.byName[trimmed] = region
+		}
+	}
+}
+
+func (a *TextureAtlas) GetRegion(name string) *AtlasRegion {
+	return a.byName[name]
+}
+
+func (a *TextureAtlas) Region(name string) *ebiten.Image {
+	if region := a.byName[name]; region != nil {
+		return region.Image
+	}
+	return nil
+}
+
+func (a *TextureAtlas) RegionsWithPrefix(prefix string) []*AtlasRegion {
+	var regions []*AtlasRegion
+	for _, region := range a.Regions {
+		if strings.HasPrefix(region.Name, prefix) {
+			regions = append(regions, region)
+		}
+	}
+
+	slices.SortStableFunc(regions, func(x, y *AtlasRegion) int {
+		if x.Name == y.Name {
+			return x.Index - y.Index
+		}
+		return naturalCompare(x.Name, y.Name)
+	})
+	return regions
+}
+
+func (a *TextureAtlas) Frames(prefix string) []*ebiten.Image {
+	regions := a.RegionsWithPrefix(prefix)
+	frames := make([]*ebiten.Image, len(regions))
+	for i, region := range regions {
+		frames[i] = region.Image
+	}
+	return frames
+}
+
+func (a *TextureAtlas) SpriteSheet(prefix string) *SpriteSheet {
+	sheet := &SpriteSheet{}
+	for _, region := range a.RegionsWithPrefix(prefix) {
+		sheet.Frames = append(sheet.Frames, region.Image)
+		sheet.FrameNames = append(sheet.FrameNames, region.Name)
+		if sheet.Image == nil && region.Page < len(a.Pages) {
+			sheet.Image = a.Pages[region.Page]
+			sheet.FrameWidth = region.OriginalSize.X
+			sheet.FrameHeight = region.OriginalSize.Y
+		}
+	}
+	return sheet
+}
+
+func (a *TextureAtlas) Register() {
+	for name, region := range a.byName {
+		RegisterSprite(name, region.Image)
+	}
+}
+
+func naturalCompare(a, b string) int {
+	for a != "" && b != "" {
+		da, db := leadingDigits(a), leadingDigits(b)
+		if da > 0 && db > 0 {
+			na, _ := strconv.ParseFloat(a[:da], 64)
+			nb, _ := strconv.ParseFloat(b[:db], 64)
+			if na != nb {
+				return int(math.Copysign(1, na-nb))
+			}
+			a, b = a[da:], b[db:]
+			continue
+		}
+		if a[0] != b[0] {
+			return int(a[0]) - int(b[0])
+		}
+		a, b = a[1:], b[1:]
+	}
+	return len(a) - len(b)
+}
+
+func leadingDigits(s string) int {
+	n := 0
+	for n < len(s) && s[n] >= '0' && s[n] <= '9' {
+		n++
+	}
+	return n
+}
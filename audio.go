@@ -365,6 +365,31 @@ func (am *AudioManager) SetSoundVolumeByName(name string, volume float64) error
 	return nil
 }
 
+func (am *AudioManager) PlayingVoices() int {
+	am.mutex.RLock()
+	defer am.mutex.RUnlock()
+
+	count := 0
+	for _, sound := range am.sounds {
+		sound.mutex.Lock()
+		for _, player := range sound.players {
+			if player.IsPlaying() {
+				count++
+			}
+		}
+		sound.mutex.Unlock()
+	}
+
+	if music := am.currentMusic; music != nil {
+		music.mutex.Lock()
+		if music.player != nil && music.player.IsPlaying() {
+			count++
+		}
+		music.mutex.Unlock()
+	}
+	return count
+}
+
 func (am *AudioManager) cleanupSoundPlayers(sound *Sound) {
 	activePlayers := make([]*audio.Player, 0)
 
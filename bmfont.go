@@ -0,0 +1,304 @@
+package life
+
+import (
+	"bytes"
+	"embed"
+	"encoding/xml"
+	"fmt"
+	"image"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"golang.org/x/image/font"
+	"golang.org/x/image/math/fixed"
+)
+
+type BitmapGlyph struct {
+	ID       rune
+	X, Y     int
+	Width    int
+	Height   int
+	XOffset  int
+	YOffset  int
+	XAdvance int
+	Page     int
+}
+
+type BitmapFont struct {
+	Face       string
+	Size       int
+	LineHeight int
+	Base       int
+	Pages      []image.Image
+	Glyphs     map[rune]*BitmapGlyph
+	Kernings   map[[2]rune]int
+}
+
+type bmfontXML struct {
+	Info struct {
+		Face string `xml:"face,attr"`
+		Size int    `xml:"size,attr"`
+	} `xml:"info"`
+	Common struct {
+		LineHeight int `xml:"lineHeight,attr"`
+		Base       int `xml:"base,attr"`
+	} `xml:"common"`
+	Pages []struct {
+		ID   int    `xml:"id,attr"`
+		File string `xml:"file,attr"`
+	} `xml:"pages>page"`
+	Chars []struct {
+		ID       int `xml:"id,attr"`
+		X        int `xml:"x,attr"`
+		Y        int `xml:"y,attr"`
+		Width    int `xml:"width,attr"`
+		Height   int `xml:"height,attr"`
+		XOffset  int `xml:"xoffset,attr"`
+		YOffset  int `xml:"yoffset,attr"`
+		XAdvance int `xml:"xadvance,attr"`
+		Page     int `xml:"page,attr"`
+	} `xml:"chars>char"`
+	Kernings []struct {
+		First  int `xml:"first,attr"`
+		Second int `xml:"second,attr"`
+		Amount int `xml:"amount,attr"`
+	} `xml:"kernings>kerning"`
+}
+
+func LoadBitmapFont(fntPath string) (*BitmapFont, error) {
+	dir := filepath.Dir(fntPath)
+	return loadBitmapFont(fntPath, os.ReadFile, func(file string) string {
+		return filepath.Join(dir, file)
+	})
+}
+
+func LoadBitmapFontFromFS(fs embed.FS, fntPath string) (*BitmapFont, error) {
+	dir := path.Dir(fntPath)
+	return loadBitmapFont(fntPath, fs.ReadFile, func(file string) string {
+		return path.Join(dir, file)
+	})
+}
+
+func loadBitmapFont(fntPath string, readFile func(string) ([]byte, error), resolve func(string) string) (*BitmapFont, error) {
+	data, err := readFile(fntPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read bitmap font %s: %w", fntPath, err)
+	}
+
+	bf := &BitmapFont{
+		Glyphs:   make(map[rune]*BitmapGlyph),
+		Kernings: make(map[[2]rune]int),
+	}
+
+	var pages map[int]string
+	if trimmed := bytes.TrimSpace(data); len(trimmed) > 0 && trimmed[0] == '<' {
+		pages, err = bf.parseXML(data)
+	} else {
+		pages, err = bf.parseText(data)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse bitmap font %s: %w", fntPath, err)
+	}
+
+	bf.Pages = make([]image.Image, len(pages))
+	for id, file := range pages {
+		if id < 0 || id >= len(pages) {
+			return nil, fmt.Errorf("bitmap font %s has invalid page id %d", fntPath, id)
+		}
+
+		pageData, err := readFile(resolve(file))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read bitmap font page %s: %w", file, err)
+		}
+		img, _, err := image.Decode(bytes.NewReader(pageData))
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode bitmap font page %s: %w", file, err)
+		}
+		bf.Pages[id] = img
+	}
+
+	return bf, nil
+}
+
+func (bf *BitmapFont) parseXML(data []byte) (map[int]string, error) {
+	var doc bmfontXML
+	if err := xml.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+
+	bf.Face = doc.Info.Face
+	bf.Size = doc.Info.Size
+	bf.LineHeight = doc.Common.LineHeight
+	bf.Base = doc.Common.Base
+
+	pages := make(map[int]string)
+	for _, p := range doc.Pages {
+		pages[p.ID] = p.File
+	}
+	for _, c := range doc.Chars {
+		bf.Glyphs[rune(c.ID)] = &BitmapGlyph{
+			ID:       rune(c.ID),
+			X:        c.X,
+			Y:        c.Y,
+			Width:    c.Width,
+			Height:   c.Height,
+			XOffset:  c.XOffset,
+			YOffset:  c.YOffset,
+			XAdvance: c.XAdvance,
+			Page:     c.Page,
+		}
+	}
+	for _, k := range doc.Kernings {
+		bf.Kernings[[2]rune{rune(k.First), rune(k.Second)}] = k.Amount
+	}
+	return pages, nil
+}
+
+func (bf *BitmapFont) parseText(data []byte) (map[int]string, error) {
+	pages := make(map[int]string)
+
+	for number, line := range strings.Split(string(data), "\n") {
+		tag, attrs := parseBMFontLine(line)
+		value := func(key string) int {
+			n, _ := strconv.Atoi(attrs[key])
+			return n
+		}
+
+		switch tag {
+		case "info":
+			bf.Face = attrs["face"]
+			bf.Size = value("size")
+		case "common":
+			bf.LineHeight = value("lineHeight")
+			bf.Base = value("base")
+		case "page":
+			if _, ok := attrs["id"]; !ok {
+				return nil, fmt.Errorf("line %d: page without id", number+1)
+			}
+			pages[value("id")] = attrs["file"]
+		case "char":
+			id := rune(value("id"))
+			bf.Glyphs[id] = &BitmapGlyph{
+				ID:       id,
+				X:        value("x"),
+				Y:        value("y"),
+				Width:    value("width"),
+				Height:   value("height"),
+				XOffset:  value("xoffset"),
+				YOffset:  value("yoffset"),
+				XAdvance: value("xadvance"),
+				Page:     value("page"),
+			}
+		case "kerning":
+			bf.Kernings[[2]rune{rune(value("first")), rune(value("second"))}] = value("amount")
+		}
+	}
+
+	return pages, nil
+}
+
+func parseBMFontLine(line string) (string, map[string]string) {
+	line = strings.TrimSpace(line)
+	end := strings.IndexAny(line, " \t")
+	if end < 0 {
+		return line, nil
+	}
+
+	tag := line[:end]
+	rest := line[end:]
+	attrs := make(map[string]string)
+
+	for {
+		rest = strings.TrimLeft(rest, " \t")
+		eq := strings.IndexByte(rest, '=')
+		if eq < 0 {
+			break
+		}
+		key := rest[:eq]
+		rest = rest[eq+1:]
+
+		var value string
+		if strings.HasPrefix(rest, "\"") {
+			closing := strings.IndexByte(rest[1:], '"')
+			if closing < 0 {
+				value, rest = rest[1:], ""
+			} else {
+				value, rest = rest[1:closing+1], rest[closing+2:]
+			}
+		} else {
+			next := strings.IndexAny(rest, " \t")
+			if next < 0 {
+				next = len(rest)
+			}
+			value, rest = rest[:next], rest[next:]
+		}
+		attrs[key] = value
+	}
+
+	return tag, attrs
+}
+
+func (bf *BitmapFont) Close() error {
+	return nil
+}
+
+func (bf *BitmapFont) Glyph(dot fixed.Point26_6, r rune) (image.Rectangle, image.Image, image.Point, fixed.Int26_6, bool) {
+	glyph, ok := bf.Glyphs[r]
+	if !ok || glyph.Page < 0 || glyph.Page >= len(bf.Pages) || bf.Pages[glyph.Page] == nil {
+		return image.Rectangle{}, nil, image.Point{}, 0, false
+	}
+
+	x := dot.X.Round() + glyph.XOffset
+	y := dot.Y.Round() - bf.Base + glyph.YOffset
+	dr := image.Rect(x, y, x+glyph.Width, y+glyph.Height)
+	return dr, bf.Pages[glyph.Page], image.Pt(glyph.X, glyph.Y), fixed.I(glyph.XAdvance), true
+}
+
+func (bf *BitmapFont) GlyphBounds(r rune) (fixed.Rectangle26_6, fixed.Int26_6, bool) {
+	glyph, ok := bf.Glyphs[r]
+	if !ok {
+		return fixed.Rectangle26_6{}, 0, false
+	}
+
+	top := glyph.YOffset - bf.Base
+	bounds := fixed.R(glyph.XOffset, top, glyph.XOffset+glyph.Width, top+glyph.Height)
+	return bounds, fixed.I(glyph.XAdvance), true
+}
+
+func (bf *BitmapFont) GlyphAdvance(r rune) (fixed.Int26_6, bool) {
+	glyph, ok := bf.Glyphs[r]
+	if !ok {
+		return 0, false
+	}
+	return fixed.I(glyph.XAdvance), true
+}
+
+func (bf *BitmapFont) Kern(r0, r1 rune) fixed.Int26_6 {
+	return fixed.I(bf.Kernings[[2]rune{r0, r1}])
+}
+
+func (bf *BitmapFont) Metrics() font.Metrics {
+	return font.Metrics{
+		Height:    fixed.I(bf.LineHeight),
+		Ascent:    fixed.I(bf.Base),
+		Descent:   fixed.I(bf.LineHeight - bf.Base),
+		CapHeight: fixed.I(bf.Base),
+		XHeight:   fixed.I(bf.Base / 2),
+	}
+}
+
+func RegisterBitmapFont(name string, bf *BitmapFont) {
+	fontMutex.Lock()
+	defer fontMutex.Unlock()
+
+	delete(fonts, name)
+	for key := range fontFaces {
+		if key.name == name {
+			delete(fontFaces, key)
+		}
+	}
+	bitmapFonts[name] = bf
+}
@@ -0,0 +1,364 @@
+package life
+
+import (
+	"fmt"
+	"image"
+	"image/color/palette"
+	"image/draw"
+	"image/gif"
+	"image/png"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/inpututil"
+)
+
+type RecorderFormat string
+
+const (
+	RecordPNG RecorderFormat = "png"
+	RecordGIF RecorderFormat = "gif"
+)
+
+type EventScreenshotData struct {
+	Path string
+	Err  error
+}
+
+type EventRecordingData struct {
+	Path    string
+	Frames  int
+	Dropped int
+	Err     error
+}
+
+type RecorderProps struct {
+	Format    RecorderFormat
+	Path      string
+	FrameSkip int
+	MaxFrames int
+	Scale     float64
+	// Hotkey toggles the recording. The zero value leaves it unbound; set
+	// Recorder.Hotkey directly to bind ebiten.KeyA.
+	Hotkey ebiten.Key
+}
+
+type Recorder struct {
+	Format    RecorderFormat
+	Path      string
+	FrameSkip int
+	MaxFrames int
+	Scale     float64
+	Hotkey    ebiten.Key
+
+	world    *World
+	session  *recording
+	frame    int
+	captured int
+	done     sync.WaitGroup
+	errMutex sync.Mutex
+	err      error
+	result   *EventRecordingData
+}
+
+type recording struct {
+	format  RecorderFormat
+	path    string
+	delay   int
+	frames  chan *image.RGBA
+	dropped int
+}
+
+const (
+	recorderQueueSize = 16
+	// GIF frames are held in memory until the recording stops, so GIF
+	// recordings without a MaxFrames are capped here.
+	defaultGIFFrames = 300
+)
+
+func (w *World) Screenshot(path string) {
+	w.captureMutex.Lock()
+	w.pendingScreenshots = append(w.pendingScreenshots, path)
+	w.captureMutex.Unlock()
+}
+
+func (w *World) NewRecorder(props *RecorderProps) *Recorder {
+	if props == nil {
+		props = &RecorderProps{}
+	}
+
+	if props.Format == "" {
+		props.Format = RecordGIF
+	}
+	if props.Path == "" {
+		if props.Format == RecordGIF {
+			props.Path = "recording.gif"
+		} else {
+			props.Path = "frames"
+		}
+	}
+	if props.Scale == 0 {
+		props.Scale = 1
+	}
+	if props.MaxFrames == 0 && props.Format == RecordGIF {
+		props.MaxFrames = defaultGIFFrames
+	}
+	if props.Hotkey == 0 {
+		props.Hotkey = KeyNone
+	}
+
+	recorder := &Recorder{
+		Format:    props.Format,
+		Path:      props.Path,
+		FrameSkip: props.FrameSkip,
+		MaxFrames: props.MaxFrames,
+		Scale:     props.Scale,
+		Hotkey:    props.Hotkey,
+		world:     w,
+	}
+
+	w.captureMutex.Lock()
+	w.recorders = append(w.recorders, recorder)
+	w.captureMutex.Unlock()
+
+	return recorder
+}
+
+func (r *Recorder) IsRecording() bool {
+	return r.session != nil
+}
+
+func (r *Recorder) Start() error {
+	if r.session != nil {
+		return nil
+	}
+
+	if r.Format == RecordPNG {
+		if err := os.MkdirAll(r.Path, 0o755); err != nil {
+			return fmt.Errorf("failed to create frame directory %s: %w", r.Path, err)
+		}
+	}
+
+	r.frame = 0
+	r.captured = 0
+	r.session = &recording{
+		format: r.Format,
+		path:   r.Path,
+		delay:  r.frameDelay(),
+		frames: make(chan *image.RGBA, recorderQueueSize),
+	}
+
+	r.done.Add(1)
+	go r.encode(r.session)
+
+	if r.world != nil {
+		r.world.Emit(EventRecordingStart, EventRecordingData{Path: r.Path})
+	}
+
+	return nil
+}
+
+func (r *Recorder) Stop() {
+	if r.session == nil {
+		return
+	}
+
+	close(r.session.frames)
+	r.session = nil
+}
+
+func (r *Recorder) Wait() error {
+	r.done.Wait()
+
+	r.errMutex.Lock()
+	defer r.errMutex.Unlock()
+	return r.err
+}
+
+func (r *Recorder) Toggle() error {
+	if r.session != nil {
+		r.Stop()
+		return nil
+	}
+	return r.Start()
+}
+
+func (r *Recorder) capture(screen *ebiten.Image) {
+	session := r.session
+	if session == nil {
+		return
+	}
+
+	r.frame++
+	if r.FrameSkip > 0 && (r.frame-1)%(r.FrameSkip+1) != 0 {
+		return
+	}
+
+	if len(session.frames) == cap(session.frames) {
+		session.dropped++
+	} else {
+		session.frames <- readScreen(screen, r.Scale)
+		r.captured++
+	}
+
+	if r.MaxFrames > 0 && r.captured >= r.MaxFrames {
+		r.Stop()
+	}
+}
+
+func (r *Recorder) encode(session *recording) {
+	defer r.done.Done()
+
+	var (
+		err       error
+		count     int
+		gifFrames []*image.Paletted
+		gifDelays []int
+	)
+	for frame := range session.frames {
+		if err != nil {
+			continue
+		}
+
+		switch session.format {
+		case RecordPNG:
+			path := filepath.Join(session.path, fmt.Sprintf("frame_%05d.png", count))
+			err = writePNG(path, frame)
+
+		case RecordGIF:
+			paletted := image.NewPaletted(frame.Bounds(), palette.Plan9)
+			draw.FloydSteinberg.Draw(paletted, frame.Bounds(), frame, image.Point{})
+			gifFrames = append(gifFrames, paletted)
+			gifDelays = append(gifDelays, session.delay)
+		}
+		count++
+	}
+
+	if err == nil && session.format == RecordGIF && len(gifFrames) > 0 {
+		err = writeGIF(session.path, gifFrames, gifDelays)
+	}
+
+	r.errMutex.Lock()
+	r.err = err
+	r.result = &EventRecordingData{
+		Path:    session.path,
+		Frames:  count,
+		Dropped: session.dropped,
+		Err:     err,
+	}
+	r.errMutex.Unlock()
+}
+
+func (r *Recorder) takeResult() *EventRecordingData {
+	r.errMutex.Lock()
+	defer r.errMutex.Unlock()
+
+	result := r.result
+	r.result = nil
+	return result
+}
+
+func (r *Recorder) frameDelay() int {
+	delay := int(float64(r.FrameSkip+1) / float64(ebiten.TPS()) * 100)
+	if delay < 2 {
+		delay = 2
+	}
+	return delay
+}
+
+func writeGIF(path string, frames []*image.Paletted, delays []int) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("failed to create recording %s: %w", path, err)
+	}
+	defer file.Close()
+
+	if err := gif.EncodeAll(file, &gif.GIF{Image: frames, Delay: delays}); err != nil {
+		return fmt.Errorf("failed to encode recording %s: %w", path, err)
+	}
+	return nil
+}
+
+func readScreen(screen *ebiten.Image, scale float64) *image.RGBA {
+	bounds := screen.Bounds()
+	full := image.NewRGBA(image.Rect(0, 0, bounds.Dx(), bounds.Dy()))
+	screen.ReadPixels(full.Pix)
+
+	if scale <= 0 || scale == 1 {
+		return full
+	}
+
+	width := int(float64(bounds.Dx()) * scale)
+	height := int(float64(bounds.Dy()) * scale)
+	if width < 1 || height < 1 {
+		return full
+	}
+
+	scaled := image.NewRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		srcY := int(float64(y) / scale)
+		for x := 0; x < width; x++ {
+			srcX := int(float64(x) / scale)
+			copy(scaled.Pix[scaled.PixOffset(x, y):scaled.PixOffset(x, y)+4], full.Pix[full.PixOffset(srcX, srcY):full.PixOffset(srcX, srcY)+4])
+		}
+	}
+	return scaled
+}
+
+func writePNG(path string, img image.Image) error {
+	if dir := filepath.Dir(path); dir != "" {
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			return fmt.Errorf("failed to create directory %s: %w", dir, err)
+		}
+	}
+
+	file, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("failed to create %s: %w", path, err)
+	}
+	defer file.Close()
+
+	if err := png.Encode(file, img); err != nil {
+		return fmt.Errorf("failed to encode %s: %w", path, err)
+	}
+	return nil
+}
+
+func (w *World) captureFrame(screen *ebiten.Image) {
+	w.captureMutex.Lock()
+	paths := w.pendingScreenshots
+	w.pendingScreenshots = nil
+	recorders := w.recorders
+	w.captureMutex.Unlock()
+
+	if len(paths) > 0 {
+		frame := readScreen(screen, 1)
+		for _, path := range paths {
+			err := writePNG(path, frame)
+			w.Emit(EventScreenshot, EventScreenshotData{Path: path, Err: err})
+		}
+	}
+
+	for _, recorder := range recorders {
+		recorder.capture(screen)
+		if result := recorder.takeResult(); result != nil {
+			w.Emit(EventRecordingStop, *result)
+		}
+	}
+}
+
+func (w *World) updateRecorderHotkeys() {
+	w.captureMutex.Lock()
+	recorders := w.recorders
+	w.captureMutex.Unlock()
+
+	for _, recorder := range recorders {
+		if recorder.Hotkey >= 0 && inpututil.IsKeyJustPressed(recorder.Hotkey) {
+			if err := recorder.Toggle(); err != nil {
+				w.Emit(EventRecordingStop, EventRecordingData{Path: recorder.Path, Err: err})
+			}
+		}
+	}
+}
@@ -0,0 +1,744 @@
+package life
+
+import (
+	"fmt"
+	"image/color"
+	"reflect"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/inpututil"
+)
+
+type ConsoleCommand struct {
+	Name        string
+	Usage       string
+	Description string
+	Run         func(w *World, args []string) (string, error)
+	Complete    func(w *World, args []string) []string
+}
+
+type Spawner func(w *World, x, y float64) *Shape
+
+type Console struct {
+	Open       bool
+	Key        ebiten.Key
+	MaxLines   int
+	Background color.Color
+	Color      color.Color
+
+	input    []rune
+	cursor   int
+	lines    []string
+	history  []string
+	browse   int
+	commands map[string]*ConsoleCommand
+	spawners map[string]Spawner
+	flags    map[string]bool
+	opened   time.Time
+}
+
+func NewConsole(key ebiten.Key) *Console {
+	c := &Console{
+		Key:        key,
+		MaxLines:   200,
+		Background: color.RGBA{10, 10, 16, 220},
+		Color:      color.RGBA{220, 220, 220, 255},
+		commands:   make(map[string]*ConsoleCommand),
+		spawners:   make(map[string]Spawner),
+		flags:      make(map[string]bool),
+	}
+	c.registerBuiltins()
+	return c
+}
+
+func (c *Console) Register(command *ConsoleCommand) {
+	c.commands[command.Name] = command
+}
+
+func (c *Console) Unregister(name string) {
+	delete(c.commands, name)
+}
+
+func (c *Console) RegisterSpawner(name string, spawner Spawner) {
+	c.spawners[name] = spawner
+}
+
+func (c *Console) Flag(name string) bool {
+	return c.flags[name]
+}
+
+func (c *Console) SetFlag(name string, value bool) {
+	c.flags[name] = value
+}
+
+func (c *Console) Print(line string) {
+	c.lines = append(c.lines, strings.Split(line, "\n")...)
+	if c.MaxLines > 0 && len(c.lines) > c.MaxLines {
+		c.lines = c.lines[len(c.lines)-c.MaxLines:]
+	}
+}
+
+func (c *Console) Printf(format string, args ...interface{}) {
+	c.Print(fmt.Sprintf(format, args...))
+}
+
+func (c *Console) Clear() {
+	c.lines = nil
+}
+
+func (c *Console) Toggle() {
+	c.Open = !c.Open
+	c.opened = time.Now()
+}
+
+func (c *Console) History() []string {
+	return slices.Clone(c.history)
+}
+
+func (c *Console) Commands() []string {
+	names := make([]string, 0, len(c.commands))
+	for name := range c.commands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func (w *World) EnableConsole(key ebiten.Key) *Console {
+	if w.Console == nil {
+		w.Console = NewConsole(key)
+	} else {
+		w.Console.Key = key
+	}
+	return w.Console
+}
+
+func (w *World) console() *Console {
+	if w.Console == nil {
+		w.Console = NewConsole(KeyNone)
+	}
+	return w.Console
+}
+
+func (w *World) RegisterCommand(command *ConsoleCommand) {
+	w.console().Register(command)
+}
+
+func (w *World) Exec(line string) (string, error) {
+	args := splitCommand(line)
+	if len(args) == 0 {
+		return "", nil
+	}
+
+	command, ok := w.console().commands[args[0]]
+	if !ok {
+		return "", fmt.Errorf("unknown command %q", args[0])
+	}
+	return command.Run(w, args[1:])
+}
+
+func splitCommand(line string) []string {
+	var args []string
+	var current strings.Builder
+	quoted, started := false, false
+
+	for _, r := range line {
+		switch {
+		case r == '"':
+			quoted = !quoted
+			started = true
+		case r == ' ' && !quoted:
+			if started {
+				args = append(args, current.String())
+				current.Reset()
+				started = false
+			}
+		default:
+			current.WriteRune(r)
+			started = true
+		}
+	}
+	if started {
+		args = append(args, current.String())
+	}
+	return args
+}
+
+func (w *World) consoleOpen() bool {
+	return w.Console != nil && w.Console.Open
+}
+
+func (w *World) updateConsole() {
+	c := w.Console
+	if c == nil {
+		return
+	}
+
+	if c.Key >= 0 && inpututil.IsKeyJustPressed(c.Key) {
+		c.Toggle()
+		return
+	}
+	if !c.Open {
+		return
+	}
+
+	toggleHeld := c.Key >= 0 && ebiten.IsKeyPressed(c.Key)
+	for _, r := range ebiten.AppendInputChars(nil) {
+		if toggleHeld && slices.Contains(keyRunes(c.Key), r) {
+			continue
+		}
+		c.input = slices.Insert(c.input, c.cursor, r)
+		c.cursor++
+	}
+
+	switch {
+	case repeatKey(ebiten.KeyBackspace):
+		if c.cursor > 0 {
+			c.input = slices.Delete(c.input, c.cursor-1, c.cursor)
+			c.cursor--
+		}
+	case repeatKey(ebiten.KeyDelete):
+		if c.cursor < len(c.input) {
+			c.input = slices.Delete(c.input, c.cursor, c.cursor+1)
+		}
+	case repeatKey(ebiten.KeyLeft):
+		c.cursor = max(0, c.cursor-1)
+	case repeatKey(ebiten.KeyRight):
+		c.cursor = min(len(c.input), c.cursor+1)
+	case inpututil.IsKeyJustPressed(ebiten.KeyHome):
+		c.cursor = 0
+	case inpututil.IsKeyJustPressed(ebiten.KeyEnd):
+		c.cursor = len(c.input)
+	case inpututil.IsKeyJustPressed(ebiten.KeyUp):
+		c.browseHistory(-1)
+	case inpututil.IsKeyJustPressed(ebiten.KeyDown):
+		c.browseHistory(1)
+	case inpututil.IsKeyJustPressed(ebiten.KeyTab):
+		w.completeConsole()
+	case inpututil.IsKeyJustPressed(ebiten.KeyEscape):
+		c.Open = false
+	case inpututil.IsKeyJustPressed(ebiten.KeyEnter) || inpututil.IsKeyJustPressed(ebiten.KeyNumpadEnter):
+		w.submitConsole()
+	}
+}
+
+func keyRunes(key ebiten.Key) []rune {
+	switch {
+	case key >= ebiten.KeyA && key <= ebiten.KeyZ:
+		return []rune{'a' + rune(key-ebiten.KeyA), 'A' + rune(key-ebiten.KeyA)}
+	case key >= ebiten.KeyDigit0 && key <= ebiten.KeyDigit9:
+		return []rune{'0' + rune(key-ebiten.KeyDigit0), []rune(")!@#$%^&*(")[key-ebiten.KeyDigit0]}
+	case key >= ebiten.KeyNumpad0 && key <= ebiten.KeyNumpad9:
+		return []rune{'0' + rune(key-ebiten.KeyNumpad0)}
+	}
+
+	switch key {
+	case ebiten.KeyGraveAccent:
+		return []rune{'`', '~'}
+	case ebiten.KeyMinus:
+		return []rune{'-', '_'}
+	case ebiten.KeyEqual:
+		return []rune{'=', '+'}
+	case ebiten.KeyBracketLeft:
+		return []rune{'[', '{'}
+	case ebiten.KeyBracketRight:
+		return []rune{']', '}'}
+	case ebiten.KeyBackslash:
+		return []rune{'\\', '|'}
+	case ebiten.KeySemicolon:
+		return []rune{';', ':'}
+	case ebiten.KeyQuote:
+		return []rune{'\'', '"'}
+	case ebiten.KeyComma:
+		return []rune{',', '<'}
+	case ebiten.KeyPeriod:
+		return []rune{'.', '>'}
+	case ebiten.KeySlash:
+		return []rune{'/', '?'}
+	case ebiten.KeySpace:
+		return []rune{' '}
+	}
+	return nil
+}
+
+func repeatKey(key ebiten.Key) bool {
+	const delay, interval = 30, 3
+
+	duration := inpututil.KeyPressDuration(key)
+	return duration == 1 || (duration >= delay && (duration-delay)%interval == 0)
+}
+
+func (c *Console) browseHistory(step int) {
+	if len(c.history) == 0 {
+		return
+	}
+
+	c.browse = max(0, min(len(c.history), c.browse+step))
+	if c.browse == len(c.history) {
+		c.input = nil
+	} else {
+		c.input = []rune(c.history[c.browse])
+	}
+	c.cursor = len(c.input)
+}
+
+func (w *World) submitConsole() {
+	c := w.Console
+	line := strings.TrimSpace(string(c.input))
+	c.input, c.cursor = nil, 0
+	if line == "" {
+		return
+	}
+
+	if len(c.history) == 0 || c.history[len(c.history)-1] != line {
+		c.history = append(c.history, line)
+	}
+	c.browse = len(c.history)
+
+	c.Print("> " + line)
+	output, err := w.Exec(line)
+	if err != nil {
+		c.Print("error: " + err.Error())
+	} else if output != "" {
+		c.Print(output)
+	}
+}
+
+func (w *World) completeConsole() {
+	c := w.Console
+	line := string(c.input[:c.cursor])
+	args := splitCommand(line)
+	if len(args) == 0 || strings.HasSuffix(line, " ") {
+		args = append(args, "")
+	}
+
+	var candidates []string
+	if len(args) == 1 {
+		candidates = c.Commands()
+	} else if command, ok := c.commands[args[0]]; ok && command.Complete != nil {
+		candidates = command.Complete(w, args[1:])
+	}
+
+	prefix := args[len(args)-1]
+	var matches []string
+	for _, candidate := range candidates {
+		if strings.HasPrefix(candidate, prefix) {
+			matches = append(matches, candidate)
+		}
+	}
+
+	switch len(matches) {
+	case 0:
+		return
+	case 1:
+		completion := matches[0]
+		if len(args) == 1 || !strings.HasSuffix(completion, ".") && !strings.HasSuffix(completion, ":") {
+			completion += " "
+		}
+		c.replaceWord(prefix, completion)
+	default:
+		c.replaceWord(prefix, commonPrefix(matches))
+		c.Print(strings.Join(matches, "  "))
+	}
+}
+
+func (c *Console) replaceWord(word, replacement string) {
+	start := c.cursor - utf8.RuneCountInString(word)
+	rest := slices.Clone(c.input[c.cursor:])
+	c.input = append(append(c.input[:start], []rune(replacement)...), rest...)
+	c.cursor = start + utf8.RuneCountInString(replacement)
+}
+
+func commonPrefix(values []string) string {
+	prefix := values[0]
+	for _, value := range values[1:] {
+		for !strings.HasPrefix(value, prefix) {
+			prefix = prefix[:len(prefix)-1]
+		}
+	}
+	return prefix
+}
+
+func (w *World) drawConsole(screen *ebiten.Image) {
+	c := w.Console
+	if c == nil || !c.Open {
+		return
+	}
+
+	bounds := screen.Bounds()
+	width := float64(bounds.Dx())
+	height := float64(bounds.Dy()) * 0.4
+
+	b := &w.perf.batch
+	b.Begin(screen)
+	var geoM ebiten.GeoM
+	b.FillRect(&geoM, width, height, c.Background, 1)
+	geoM.Translate(0, height)
+	b.FillRect(&geoM, width, 1, c.Color, 0.5)
+	b.End()
+
+	_, lineHeight := MeasureText(&TextProps{Text: "Ag", VerticalAlign: AlignTop})
+
+	prompt := "> " + string(c.input[:c.cursor])
+	if time.Since(c.opened)%time.Second < time.Second/2 {
+		prompt += "_"
+	} else {
+		prompt += " "
+	}
+	prompt += string(c.input[c.cursor:])
+
+	y := height - 8
+	DrawText(screen, &TextProps{Text: prompt, X: 8, Y: y, Color: c.Color, VerticalAlign: AlignBottom})
+	y -= lineHeight + 4
+
+	for i := len(c.lines) - 1; i >= 0 && y > 0; i-- {
+		DrawText(screen, &TextProps{Text: c.lines[i], X: 8, Y: y, Color: c.Color, VerticalAlign: AlignBottom})
+		y -= lineHeight
+	}
+}
+
+func (c *Console) registerBuiltins() {
+	c.Register(&ConsoleCommand{
+		Name:        "help",
+		Usage:       "help [command]",
+		Description: "list commands or show usage",
+		Run: func(w *World, args []string) (string, error) {
+			if len(args) > 0 {
+				command, ok := w.Console.commands[args[0]]
+				if !ok {
+					return "", fmt.Errorf("unknown command %q", args[0])
+				}
+				return fmt.Sprintf("%s - %s", command.Usage, command.Description), nil
+			}
+
+			var lines []string
+			for _, name := range w.Console.Commands() {
+				command := w.Console.commands[name]
+				lines = append(lines, fmt.Sprintf("%-24s %s", command.Usage, command.Description))
+			}
+			return strings.Join(lines, "\n"), nil
+		},
+		Complete: func(w *World, args []string) []string {
+			return w.Console.Commands()
+		},
+	})
+
+	c.Register(&ConsoleCommand{
+		Name:        "clear",
+		Usage:       "clear",
+		Description: "clear console output",
+		Run: func(w *World, args []string) (string, error) {
+			w.Console.Clear()
+			return "", nil
+		},
+	})
+
+	c.Register(&ConsoleCommand{
+		Name:        "level",
+		Usage:       "level <index|name>",
+		Description: "switch to a level",
+		Run: func(w *World, args []string) (string, error) {
+			if len(args) == 0 {
+				return fmt.Sprintf("level %d of %d", w.CurrentLevel, len(w.Levels)), nil
+			}
+
+			index, err := strconv.Atoi(args[0])
+			if err != nil {
+				index = slices.IndexFunc(w.Levels, func(level Level) bool { return level.Name == args[0] })
+			}
+			if index < 0 || index >= len(w.Levels) {
+				return "", fmt.Errorf("no level %q", args[0])
+			}
+			if w.IsTransitioning() {
+				return "", fmt.Errorf("a level transition is already running")
+			}
+			w.SwitchToLevel(index)
+			return fmt.Sprintf("switching to level %d", index), nil
+		},
+		Complete: func(w *World, args []string) []string {
+			var candidates []string
+			for i, level := range w.Levels {
+				candidates = append(candidates, strconv.Itoa(i))
+				if level.Name != "" {
+					candidates = append(candidates, level.Name)
+				}
+			}
+			return candidates
+		},
+	})
+
+	c.Register(&ConsoleCommand{
+		Name:        "spawn",
+		Usage:       "spawn <name> [x] [y]",
+		Description: "spawn a registered object",
+		Run: func(w *World, args []string) (string, error) {
+			if len(args) == 0 {
+				return "", fmt.Errorf("usage: spawn <name> [x] [y]")
+			}
+			spawner, ok := w.Console.spawners[args[0]]
+			if !ok {
+				return "", fmt.Errorf("no spawner %q", args[0])
+			}
+
+			x, y := w.Mouse.X, w.Mouse.Y
+			if len(args) >= 3 {
+				var errX, errY error
+				x, errX = strconv.ParseFloat(args[1], 64)
+				y, errY = strconv.ParseFloat(args[2], 64)
+				if errX != nil || errY != nil {
+					return "", fmt.Errorf("invalid position %s %s", args[1], args[2])
+				}
+			}
+
+			shape := spawner(w, x, y)
+			if shape == nil {
+				return "", fmt.Errorf("spawner %q returned nothing", args[0])
+			}
+			if shape.world == nil {
+				w.Register(shape)
+			}
+			return fmt.Sprintf("spawned %s at %.0f,%.0f", shape.Name, x, y), nil
+		},
+		Complete: func(w *World, args []string) []string {
+			if len(args) > 1 {
+				return nil
+			}
+			names := make([]string, 0, len(w.Console.spawners))
+			for name := range w.Console.spawners {
+				names = append(names, name)
+			}
+			sort.Strings(names)
+			return names
+		},
+	})
+
+	c.Register(&ConsoleCommand{
+		Name:        "set",
+		Usage:       "set <object.Field> <value>",
+		Description: "set an exported field",
+		Run: func(w *World, args []string) (string, error) {
+			if len(args) < 2 {
+				return "", fmt.Errorf("usage: set <object.Field> <value>")
+			}
+			target, field, err := w.consoleField(args[0])
+			if err != nil {
+				return "", err
+			}
+			value := strings.Join(args[1:], " ")
+			if err := setConsoleValue(field, value); err != nil {
+				return "", fmt.Errorf("cannot set %s: %w", args[0], err)
+			}
+			if shape, ok := target.(*Shape); ok && shape.Body != nil {
+				name := args[0][strings.LastIndex(args[0], ".")+1:]
+				if name == "X" || name == "Y" {
+					shape.SetPosition(shape.X, shape.Y)
+				}
+			}
+			return fmt.Sprintf("%s = %v", args[0], field.Interface()), nil
+		},
+		Complete: completeFields,
+	})
+
+	c.Register(&ConsoleCommand{
+		Name:        "get",
+		Usage:       "get <object.Field>",
+		Description: "print an exported field",
+		Run: func(w *World, args []string) (string, error) {
+			if len(args) == 0 {
+				return "", fmt.Errorf("usage: get <object.Field>")
+			}
+			_, field, err := w.consoleField(args[0])
+			if err != nil {
+				return "", err
+			}
+			return fmt.Sprintf("%s = %v", args[0], field.Interface()), nil
+		},
+		Complete: completeFields,
+	})
+
+	c.Register(&ConsoleCommand{
+		Name:        "pause",
+		Usage:       "pause",
+		Description: "toggle world pause",
+		Run: func(w *World, args []string) (string, error) {
+			if w.Paused {
+				w.Resume()
+				return "resumed", nil
+			}
+			w.Pause()
+			return "paused", nil
+		},
+	})
+
+	c.Register(&ConsoleCommand{
+		Name:        "timescale",
+		Usage:       "timescale <scale>",
+		Description: "set game time scale",
+		Run: func(w *World, args []string) (string, error) {
+			if len(args) == 0 {
+				return fmt.Sprintf("timescale %g", w.TimeScale), nil
+			}
+			scale, err := strconv.ParseFloat(args[0], 64)
+			if err != nil {
+				return "", fmt.Errorf("invalid scale %q", args[0])
+			}
+			w.SetTimeScale(scale)
+			return fmt.Sprintf("timescale %g", w.TimeScale), nil
+		},
+	})
+
+	// The engine has no notion of damage, so god only flips a flag that games
+	// check with Console.Flag("god").
+	c.Register(&ConsoleCommand{
+		Name:        "god",
+		Usage:       "god",
+		Description: "toggle the god flag read by the game",
+		Run: func(w *World, args []string) (string, error) {
+			enabled := !w.Console.Flag("god")
+			w.Console.SetFlag("god", enabled)
+			if enabled {
+				return "god mode on", nil
+			}
+			return "god mode off", nil
+		},
+	})
+
+	c.Register(&ConsoleCommand{
+		Name:        "list",
+		Usage:       "list [tag:|name:|type:value]",
+		Description: "list objects",
+		Run: func(w *World, args []string) (string, error) {
+			objects := w.GetAllElements()
+			if len(args) > 0 {
+				kind, value, _ := strings.Cut(args[0], ":")
+				switch kind {
+				case "tag":
+					objects = w.GetElementsByTagName(value)
+				case "name":
+					objects = w.GetElementsByName(value)
+				case "type":
+					objects = w.GetElementsByType(ShapeType(value))
+				default:
+					return "", fmt.Errorf("unknown filter %q", kind)
+				}
+			}
+
+			lines := []string{fmt.Sprintf("%d objects", len(objects))}
+			for _, obj := range objects {
+				lines = append(lines, fmt.Sprintf("%-16s %-10s %-10s %.0f,%.0f", obj.Name, obj.Tag, obj.Type, obj.X, obj.Y))
+			}
+			return strings.Join(lines, "\n"), nil
+		},
+		Complete: func(w *World, args []string) []string {
+			seen := make(map[string]bool)
+			candidates := []string{"tag:", "name:", "type:"}
+			for _, obj := range w.GetAllElements() {
+				for _, candidate := range []string{"tag:" + obj.Tag, "name:" + obj.Name, "type:" + string(obj.Type)} {
+					if !seen[candidate] {
+						seen[candidate] = true
+						candidates = append(candidates, candidate)
+					}
+				}
+			}
+			return candidates
+		},
+	})
+}
+
+func (w *World) consoleField(path string) (interface{}, reflect.Value, error) {
+	dot := strings.LastIndex(path, ".")
+	if dot <= 0 || dot == len(path)-1 {
+		return nil, reflect.Value{}, fmt.Errorf("expected object.Field, got %q", path)
+	}
+	name, fieldName := path[:dot], path[dot+1:]
+
+	var target interface{}
+	if name == "world" {
+		target = w
+	} else if shape := w.GetElementByName(name); shape != nil {
+		target = shape
+	} else {
+		return nil, reflect.Value{}, fmt.Errorf("no object named %q", name)
+	}
+
+	value := reflect.ValueOf(target).Elem()
+	info, ok := value.Type().FieldByName(fieldName)
+	if !ok || len(info.Index) != 1 || !info.IsExported() {
+		return nil, reflect.Value{}, fmt.Errorf("%s has no field %q", name, fieldName)
+	}
+	return target, value.Field(info.Index[0]), nil
+}
+
+func setConsoleValue(field reflect.Value, value string) error {
+	switch field.Kind() {
+	case reflect.Float32, reflect.Float64:
+		v, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return err
+		}
+		field.SetFloat(v)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		v, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			return err
+		}
+		field.SetInt(v)
+	case reflect.Bool:
+		v, err := strconv.ParseBool(value)
+		if err != nil {
+			return err
+		}
+		field.SetBool(v)
+	case reflect.String:
+		field.SetString(value)
+	default:
+		return fmt.Errorf("unsupported type %s", field.Type())
+	}
+	return nil
+}
+
+func completeFields(w *World, args []string) []string {
+	if len(args) > 1 {
+		return nil
+	}
+
+	prefix := ""
+	if len(args) == 1 {
+		prefix = args[0]
+	}
+
+	dot := strings.LastIndex(prefix, ".")
+	if dot < 0 {
+		candidates := []string{"world."}
+		for _, obj := range w.GetAllElements() {
+			candidates = append(candidates, obj.Name+".")
+		}
+		return candidates
+	}
+
+	var target reflect.Type
+	if prefix[:dot] == "world" {
+		target = reflect.TypeOf(World{})
+	} else {
+		target = reflect.TypeOf(Shape{})
+	}
+
+	var candidates []string
+	for i := 0; i < target.NumField(); i++ {
+		field := target.Field(i)
+		if !field.IsExported() || field.Anonymous {
+			continue
+		}
+		switch field.Type.Kind() {
+		case reflect.Float32, reflect.Float64, reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64, reflect.Bool, reflect.String:
+			candidates = append(candidates, prefix[:dot+1]+field.Name)
+		}
+	}
+	return candidates
+}
@@ -4,6 +4,8 @@ import (
 	"math"
 	"math/rand"
 	"time"
+
+	"github.com/hajimehoshi/ebiten/v2"
 )
 
 type AxisX string
@@ -46,6 +48,9 @@ const (
 	ShapeRect      ShapeType = "rectangle"
 	ShapeLine      ShapeType = "line"
 	ShapeDot       ShapeType = "dot"
+	ShapeEllipse   ShapeType = "ellipse"
+	ShapeArc       ShapeType = "arc"
+	ShapeText      ShapeType = "text"
 )
 
 type PatternType string
@@ -55,6 +60,9 @@ const (
 	PatternColor      PatternType = "color"
 	PatternSolidColor PatternType = "color"
 	PatternGradient   PatternType = "gradient"
+	PatternNone       PatternType = "none"
+	PatternTile       PatternType = "tile"
+	PatternNineSlice  PatternType = "nine-slice"
 )
 
 type CursorType string
@@ -65,8 +73,11 @@ const (
 	CursorCrosshair CursorType = "crosshair"
 	CursorMove      CursorType = "move"
 	CursorText      CursorType = "text"
+	CursorHidden    CursorType = "hidden"
 )
 
+const KeyNone ebiten.Key = -1
+
 func ID() string {
 	const charset = "abcdefghijklmnopqrstuvwxyz0123456789"
 	b := make([]byte, 7)
@@ -0,0 +1,156 @@
+package life
+
+import (
+	"sync"
+
+	"github.com/hajimehoshi/ebiten/v2"
+)
+
+type CustomCursor struct {
+	Image   *ebiten.Image
+	Hotspot Vector2
+	Scale   float64
+}
+
+var (
+	cursorMutex   sync.RWMutex
+	customCursors = map[CursorType]*CustomCursor{}
+)
+
+func RegisterCursor(name CursorType, img *ebiten.Image, hotspotX, hotspotY float64) *CustomCursor {
+	cursor := &CustomCursor{
+		Image:   img,
+		Hotspot: Vector2{X: hotspotX, Y: hotspotY},
+		Scale:   1,
+	}
+
+	cursorMutex.Lock()
+	customCursors[name] = cursor
+	cursorMutex.Unlock()
+	return cursor
+}
+
+func UnregisterCursor(name CursorType) {
+	cursorMutex.Lock()
+	delete(customCursors, name)
+	cursorMutex.Unlock()
+}
+
+func GetCursor(name CursorType) *CustomCursor {
+	cursorMutex.RLock()
+	defer cursorMutex.RUnlock()
+	return customCursors[name]
+}
+
+type cursorState struct {
+	active   CursorType
+	shape    ebiten.CursorShapeType
+	mode     ebiten.CursorModeType
+	custom   *CustomCursor
+	captured bool
+	applied  bool
+	lastX    int
+	lastY    int
+}
+
+func (w *World) SetCursor(cursor CursorType) {
+	w.Cursor = cursor
+}
+
+func (w *World) ActiveCursor() CursorType {
+	return w.cursorState.active
+}
+
+func (w *World) CaptureCursor(capture bool) {
+	w.cursorState.captured = capture
+	w.cursorState.lastX, w.cursorState.lastY = ebiten.CursorPosition()
+}
+
+func (w *World) IsCursorCaptured() bool {
+	return w.cursorState.captured
+}
+
+func (w *World) hoveredCursor() CursorType {
+	var top *Shape
+	for _, obj := range w.HoveredObjects() {
+		if obj.Cursor == "" {
+			continue
+		}
+		if top == nil || compareDrawOrder(obj, top) > 0 {
+			top = obj
+		}
+	}
+
+	if top != nil {
+		return top.Cursor
+	}
+	if w.Cursor == "" {
+		return CursorDefault
+	}
+	return w.Cursor
+}
+
+func (w *World) updateCursor() {
+	state := &w.cursorState
+
+	x, y := ebiten.CursorPosition()
+	mouse := w.WindowToView(float64(x), float64(y))
+	w.Mouse.X = mouse.X
+	w.Mouse.Y = mouse.Y
+	delta := mouse.Sub(w.WindowToView(float64(state.lastX), float64(state.lastY)))
+	w.Mouse.DeltaX = delta.X
+	w.Mouse.DeltaY = delta.Y
+	state.lastX, state.lastY = x, y
+
+	cursor := w.hoveredCursor()
+	state.active = cursor
+	state.custom = GetCursor(cursor)
+
+	mode := ebiten.CursorModeVisible
+	shape := ebiten.CursorShapeDefault
+	switch {
+	case state.captured:
+		mode = ebiten.CursorModeCaptured
+	case cursor == CursorHidden || state.custom != nil:
+		mode = ebiten.CursorModeHidden
+	case cursor == CursorPointer:
+		shape = ebiten.CursorShapePointer
+	case cursor == CursorCrosshair:
+		shape = ebiten.CursorShapeCrosshair
+	case cursor == CursorMove:
+		shape = ebiten.CursorShapeMove
+	case cursor == CursorText:
+		shape = ebiten.CursorShapeText
+	}
+
+	if !state.applied || mode != state.mode {
+		ebiten.SetCursorMode(mode)
+		state.mode = mode
+	}
+	if !state.applied || shape != state.shape {
+		ebiten.SetCursorShape(shape)
+		state.shape = shape
+	}
+	state.applied = true
+}
+
+func (w *World) drawCursor(screen *ebiten.Image) {
+	custom := w.cursorState.custom
+	if custom == nil || custom.Image == nil || w.cursorState.captured {
+		return
+	}
+
+	scale := custom.Scale
+	if scale == 0 {
+		scale = 1
+	}
+	scale *= w.DeviceScaleFactor()
+
+	x, y := ebiten.CursorPosition()
+	op := &ebiten.DrawImageOptions{}
+	op.GeoM.Translate(-custom.Hotspot.X, -custom.Hotspot.Y)
+	op.GeoM.Scale(scale, scale)
+	op.GeoM.Translate(float64(x), float64(y))
+	screen.DrawImage(custom.Image, op)
+	countDrawCall()
+}
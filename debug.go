@@ -0,0 +1,213 @@
+package life
+
+import (
+	"fmt"
+	"image/color"
+	"strings"
+	"time"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/inpututil"
+)
+
+type PerfSection int
+
+const (
+	PerfPhysics PerfSection = iota
+	PerfParallax
+	PerfEmitters
+	PerfTypewriters
+	PerfAudio
+	PerfCollisions
+	PerfScheduler
+	PerfTransition
+	PerfObjects
+	PerfTick
+	PerfInput
+	PerfBackground
+	PerfShapes
+	PerfLighting
+	PerfRender
+	PerfCapture
+	PerfPresent
+	perfSectionCount
+)
+
+var perfSectionNames = [perfSectionCount]string{
+	"physics", "parallax", "emitters", "typewriters", "audio", "collisions", "scheduler",
+	"transition", "objects", "tick", "input", "background", "shapes", "lighting", "render", "capture", "present",
+}
+
+func (p PerfSection) String() string {
+	if p < 0 || p >= perfSectionCount {
+		return "unknown"
+	}
+	return perfSectionNames[p]
+}
+
+const frameHistory = 120
+
+type PerfStats struct {
+	FPS        float64
+	TPS        float64
+	FrameTime  time.Duration
+	UpdateTime time.Duration
+	DrawTime   time.Duration
+	Sections   [perfSectionCount]time.Duration
+
+	Objects   int
+	Bodies    int
+	Contacts  int
+	DrawCalls int
+	Voices    int
+	Level     int
+	LevelName string
+}
+
+type perfState struct {
+	stats      PerfStats
+	frames     [frameHistory]time.Duration
+	frameIndex int
+	lastFrame  time.Time
+	drawStart  time.Time
+	drawCalls  int64
+	batch      Batch
+}
+
+func (p *perfState) lap(section PerfSection, start time.Time) time.Time {
+	now := time.Now()
+	p.stats.Sections[section] = now.Sub(start)
+	return now
+}
+
+func (w *World) Stats() PerfStats {
+	stats := w.perf.stats
+	stats.FPS = ebiten.ActualFPS()
+	stats.TPS = ebiten.ActualTPS()
+	stats.Level = w.CurrentLevel
+	if w.CurrentLevel >= 0 && w.CurrentLevel < len(w.Levels) {
+		stats.LevelName = w.Levels[w.CurrentLevel].Name
+	}
+
+	w.mutex.RLock()
+	stats.Objects = len(w.Objects)
+	w.mutex.RUnlock()
+
+	if w.PhysicsWorld != nil {
+		stats.Bodies = w.PhysicsWorld.GetBodyCount()
+		stats.Contacts = w.PhysicsWorld.GetContactCount()
+	}
+	if w.AudioManager != nil {
+		stats.Voices = w.AudioManager.PlayingVoices()
+	}
+	return stats
+}
+
+func (w *World) FrameTimes() []time.Duration {
+	frames := make([]time.Duration, 0, frameHistory)
+	for i := 0; i < frameHistory; i++ {
+		if frame := w.perf.frames[(w.perf.frameIndex+i)%frameHistory]; frame > 0 {
+			frames = append(frames, frame)
+		}
+	}
+	return frames
+}
+
+func (w *World) ToggleDebugHUD() {
+	w.DebugHUD = !w.DebugHUD
+}
+
+func (w *World) updateDebugHUD() {
+	if w.DebugKey >= 0 && inpututil.IsKeyJustPressed(w.DebugKey) {
+		w.ToggleDebugHUD()
+	}
+}
+
+func (w *World) beginFrame() {
+	now := time.Now()
+	calls := drawCallCount.Load()
+	if !w.perf.lastFrame.IsZero() {
+		frame := now.Sub(w.perf.lastFrame)
+		w.perf.stats.FrameTime = frame
+		w.perf.stats.DrawCalls = int(calls - w.perf.drawCalls)
+		w.perf.frames[w.perf.frameIndex] = frame
+		w.perf.frameIndex = (w.perf.frameIndex + 1) % frameHistory
+	}
+	w.perf.lastFrame = now
+	w.perf.drawStart = now
+	w.perf.drawCalls = calls
+}
+
+func (w *World) endFrame() {
+	w.perf.stats.DrawTime = time.Since(w.perf.drawStart)
+}
+
+func (w *World) drawDebugHUD(screen *ebiten.Image) {
+	if !w.DebugHUD {
+		return
+	}
+
+	stats := w.Stats()
+
+	var lines []string
+	lines = append(lines,
+		fmt.Sprintf("FPS %.1f  TPS %.1f", stats.FPS, stats.TPS),
+		fmt.Sprintf("frame %s  update %s  draw %s", formatPerf(stats.FrameTime), formatPerf(stats.UpdateTime), formatPerf(stats.DrawTime)),
+		fmt.Sprintf("objects %d  bodies %d  contacts %d", stats.Objects, stats.Bodies, stats.Contacts),
+		fmt.Sprintf("draw calls %d  voices %d", stats.DrawCalls, stats.Voices),
+		fmt.Sprintf("level %d %s", stats.Level, stats.LevelName),
+	)
+	for section := PerfSection(0); section < perfSectionCount; section++ {
+		lines = append(lines, fmt.Sprintf("  %-12s %s", section, formatPerf(stats.Sections[section])))
+	}
+	text := strings.Join(lines, "\n")
+
+	props := &TextProps{
+		Text:          text,
+		X:             8,
+		Y:             8,
+		Color:         color.RGBA{230, 230, 230, 255},
+		VerticalAlign: AlignTop,
+	}
+	width, height := MeasureText(props)
+
+	const graphHeight = 48.0
+	panelWidth := max(width, frameHistory*2) + 16
+	panelHeight := height + graphHeight + 24
+
+	b := &w.perf.batch
+	b.Begin(screen)
+
+	var geoM ebiten.GeoM
+	b.FillRect(&geoM, panelWidth, panelHeight, color.RGBA{0, 0, 0, 180}, 1)
+
+	graphTop := height + 16
+	budget := time.Second / 60
+	frames := w.FrameTimes()
+	for i, frame := range frames {
+		barHeight := min(graphHeight, graphHeight*float64(frame)/float64(2*budget))
+
+		c := color.RGBA{80, 220, 100, 255}
+		switch {
+		case frame > 2*budget:
+			c = color.RGBA{230, 70, 70, 255}
+		case frame > budget:
+			c = color.RGBA{230, 200, 60, 255}
+		}
+
+		geoM.Reset()
+		geoM.Translate(8+float64(i*2), graphTop+graphHeight-barHeight)
+		b.FillRect(&geoM, 2, barHeight, c, 1)
+	}
+
+	geoM.Reset()
+	geoM.Translate(8, graphTop+graphHeight/2)
+	b.FillRect(&geoM, frameHistory*2, 1, color.RGBA{255, 255, 255, 90}, 1)
+	b.End()
+
+	DrawText(screen, props)
+}
+
+func formatPerf(d time.Duration) string {
+	return fmt.Sprintf("%.2fms", float64(d)/float64(time.Millisecond))
+}
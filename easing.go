@@ -0,0 +1,206 @@
+package life
+
+import "math"
+
+type EasingFunc func(t float64) float64
+
+func Linear(t float64) float64 {
+	return t
+}
+
+func EaseInQuad(t float64) float64 {
+	return t * t
+}
+
+func EaseOutQuad(t float64) float64 {
+	return t * (2 - t)
+}
+
+func EaseInOutQuad(t float64) float64 {
+	if t < 0.5 {
+		return 2 * t * t
+	}
+	return -1 + (4-2*t)*t
+}
+
+func EaseInCubic(t float64) float64 {
+	return t * t * t
+}
+
+func EaseOutCubic(t float64) float64 {
+	t--
+	return t*t*t + 1
+}
+
+func EaseInOutCubic(t float64) float64 {
+	if t < 0.5 {
+		return 4 * t * t * t
+	}
+	t = 2*t - 2
+	return t*t*t/2 + 1
+}
+
+func EaseInQuart(t float64) float64 {
+	return t * t * t * t
+}
+
+func EaseOutQuart(t float64) float64 {
+	t--
+	return 1 - t*t*t*t
+}
+
+func EaseInOutQuart(t float64) float64 {
+	if t < 0.5 {
+		return 8 * t * t * t * t
+	}
+	t--
+	return 1 - 8*t*t*t*t
+}
+
+func EaseInQuint(t float64) float64 {
+	return t * t * t * t * t
+}
+
+func EaseOutQuint(t float64) float64 {
+	t--
+	return t*t*t*t*t + 1
+}
+
+func EaseInOutQuint(t float64) float64 {
+	if t < 0.5 {
+		return 16 * t * t * t * t * t
+	}
+	t = 2*t - 2
+	return t*t*t*t*t/2 + 1
+}
+
+func EaseInSine(t float64) float64 {
+	return 1 - math.Cos(t*math.Pi/2)
+}
+
+func EaseOutSine(t float64) float64 {
+	return math.Sin(t * math.Pi / 2)
+}
+
+func EaseInOutSine(t float64) float64 {
+	return -(math.Cos(math.Pi*t) - 1) / 2
+}
+
+func EaseInExpo(t float64) float64 {
+	if t == 0 {
+		return 0
+	}
+	return math.Pow(2, 10*t-10)
+}
+
+func EaseOutExpo(t float64) float64 {
+	if t == 1 {
+		return 1
+	}
+	return 1 - math.Pow(2, -10*t)
+}
+
+func EaseInOutExpo(t float64) float64 {
+	switch {
+	case t == 0:
+		return 0
+	case t == 1:
+		return 1
+	case t < 0.5:
+		return math.Pow(2, 20*t-10) / 2
+	}
+	return (2 - math.Pow(2, -20*t+10)) / 2
+}
+
+func EaseInCirc(t float64) float64 {
+	return 1 - math.Sqrt(1-t*t)
+}
+
+func EaseOutCirc(t float64) float64 {
+	t--
+	return math.Sqrt(1 - t*t)
+}
+
+func EaseInOutCirc(t float64) float64 {
+	if t < 0.5 {
+		return (1 - math.Sqrt(1-4*t*t)) / 2
+	}
+	t = 2*t - 2
+	return (math.Sqrt(1-t*t) + 1) / 2
+}
+
+const (
+	easeBack        = 1.70158
+	easeBackInOut   = easeBack * 1.525
+	easeElastic     = 2 * math.Pi / 3
+	easeElasticEdge = 2 * math.Pi / 4.5
+)
+
+func EaseInBack(t float64) float64 {
+	return (easeBack+1)*t*t*t - easeBack*t*t
+}
+
+func EaseOutBack(t float64) float64 {
+	t--
+	return 1 + (easeBack+1)*t*t*t + easeBack*t*t
+}
+
+func EaseInOutBack(t float64) float64 {
+	if t < 0.5 {
+		return (4 * t * t * ((easeBackInOut+1)*2*t - easeBackInOut)) / 2
+	}
+	t = 2*t - 2
+	return (t*t*((easeBackInOut+1)*t+easeBackInOut) + 2) / 2
+}
+
+func EaseInElastic(t float64) float64 {
+	if t == 0 || t == 1 {
+		return t
+	}
+	return -math.Pow(2, 10*t-10) * math.Sin((t*10-10.75)*easeElastic)
+}
+
+func EaseOutElastic(t float64) float64 {
+	if t == 0 || t == 1 {
+		return t
+	}
+	return math.Pow(2, -10*t)*math.Sin((t*10-0.75)*easeElastic) + 1
+}
+
+func EaseInOutElastic(t float64) float64 {
+	switch {
+	case t == 0 || t == 1:
+		return t
+	case t < 0.5:
+		return -(math.Pow(2, 20*t-10) * math.Sin((20*t-11.125)*easeElasticEdge)) / 2
+	}
+	return math.Pow(2, -20*t+10)*math.Sin((20*t-11.125)*easeElasticEdge)/2 + 1
+}
+
+func EaseInBounce(t float64) float64 {
+	return 1 - EaseOutBounce(1-t)
+}
+
+func EaseOutBounce(t float64) float64 {
+	const n, d = 7.5625, 2.75
+
+	switch {
+	case t < 1/d:
+		return n * t * t
+	case t < 2/d:
+		t -= 1.5 / d
+		return n*t*t + 0.75
+	case t < 2.5/d:
+		t -= 2.25 / d
+		return n*t*t + 0.9375
+	}
+	t -= 2.625 / d
+	return n*t*t + 0.984375
+}
+
+func EaseInOutBounce(t float64) float64 {
+	if t < 0.5 {
+		return (1 - EaseOutBounce(1-2*t)) / 2
+	}
+	return (1 + EaseOutBounce(2*t-1)) / 2
+}
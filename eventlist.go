@@ -13,6 +13,18 @@ const (
 	EventClick           EventType = "click"
 	EventCollision       EventType = "collision"
 	EventDirectionChange EventType = "event-direction-change"
+	EventScreenshot      EventType = "screenshot"
+	EventRecordingStart  EventType = "recording-start"
+	EventRecordingStop   EventType = "recording-stop"
+	EventTypewriterChar  EventType = "typewriter-char"
+	EventTypewriterDone  EventType = "typewriter-finished"
+	EventAnimationFrame  EventType = "animation-frame"
+	EventAnimationFinish EventType = "animation-finish"
+	EventAnimationState  EventType = "animation-state"
+	EventResize          EventType = "resize"
+	EventFocusLost       EventType = "focus-lost"
+	EventFocusGained     EventType = "focus-gained"
+	EventWindowSettings  EventType = "window-settings-error"
 )
 
 type EventDirectionChangeData struct {
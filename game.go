@@ -1,6 +1,10 @@
 package life
 
-import "github.com/hajimehoshi/ebiten/v2"
+import (
+	"time"
+
+	"github.com/hajimehoshi/ebiten/v2"
+)
 
 type Game struct {
 	world *World
@@ -17,23 +21,49 @@ func (g *Game) Update() error {
 }
 
 func (g *Game) Draw(screen *ebiten.Image) {
-	g.world.Draw(screen)
-	g.world.Render(screen)
+	if g.world.skipFrame() {
+		return
+	}
+
+	g.world.beginFrame()
+	canvas := g.world.viewCanvas(screen)
+
+	g.world.Draw(canvas)
+	lap := time.Now()
+	g.world.Render(canvas)
+	g.world.drawTransition(canvas)
+	lap = g.world.perf.lap(PerfRender, lap)
+	g.world.captureFrame(canvas)
+	lap = g.world.perf.lap(PerfCapture, lap)
+
+	g.world.presentView(screen, canvas)
+	g.world.drawCursor(screen)
+	g.world.perf.lap(PerfPresent, lap)
+	g.world.endFrame()
+
+	g.world.drawDebugHUD(screen)
+	g.world.drawConsole(screen)
 }
 
 func (g *Game) Layout(outsideWidth, outsideHeight int) (screenWidth, screenHeight int) {
-	return g.world.Width, g.world.Height
+	scale := g.world.DeviceScaleFactor()
+	g.world.updateViewport(int(float64(outsideWidth)*scale), int(float64(outsideHeight)*scale))
+	return g.world.viewport.windowWidth, g.world.viewport.windowHeight
 }
 
 func (g *Game) Run() error {
-	ebiten.SetWindowSize(g.world.Width, g.world.Height)
-	ebiten.SetWindowTitle(g.world.Title)
-
-	ebiten.SetWindowResizingMode(ebiten.WindowResizingModeEnabled)
+	if g.world.Window.Persist {
+		g.world.loadPersistedWindowSettings()
+	}
+	g.world.applyWindowSettings()
 
 	if g.world.Init != nil {
 		g.world.SelectLevel(0)
 	}
 
-	return ebiten.RunGame(g)
+	err := ebiten.RunGame(g)
+	if g.world.Window.Persist {
+		g.world.savePersistedWindowSettings()
+	}
+	return err
 }
@@ -0,0 +1,196 @@
+package life
+
+import (
+	"cmp"
+	"slices"
+	"sort"
+	"sync/atomic"
+)
+
+type RenderLayer string
+
+const (
+	LayerBackground RenderLayer = "background"
+	LayerWorld      RenderLayer = "world"
+	LayerForeground RenderLayer = "foreground"
+	LayerHUD        RenderLayer = "hud"
+)
+
+type Layer struct {
+	Name        RenderLayer
+	Order       int
+	Visible     bool
+	ScreenSpace bool
+}
+
+func defaultLayers() []*Layer {
+	return []*Layer{
+		{Name: LayerBackground, Order: 0, Visible: true},
+		{Name: LayerWorld, Order: 100, Visible: true},
+		{Name: LayerForeground, Order: 200, Visible: true},
+		{Name: LayerHUD, Order: 300, Visible: true, ScreenSpace: true},
+	}
+}
+
+func (w *World) AddLayer(name RenderLayer, order int, screenSpace bool) *Layer {
+	w.layerMutex.Lock()
+	defer w.layerMutex.Unlock()
+
+	for _, layer := range w.layers {
+		if layer.Name == name {
+			layer.Order = order
+			layer.ScreenSpace = screenSpace
+			w.sortLayers()
+			return layer
+		}
+	}
+
+	layer := &Layer{Name: name, Order: order, Visible: true, ScreenSpace: screenSpace}
+	w.layers = append(w.layers, layer)
+	w.sortLayers()
+	return layer
+}
+
+func (w *World) GetLayer(name RenderLayer) *Layer {
+	w.layerMutex.RLock()
+	defer w.layerMutex.RUnlock()
+
+	return w.findLayer(name)
+}
+
+func (w *World) Layers() []*Layer {
+	w.layerMutex.RLock()
+	defer w.layerMutex.RUnlock()
+
+	result := make([]*Layer, len(w.layers))
+	copy(result, w.layers)
+	return result
+}
+
+func (w *World) SetLayerVisible(name RenderLayer, visible bool) {
+	w.layerMutex.Lock()
+	defer w.layerMutex.Unlock()
+
+	if layer := w.findLayer(name); layer != nil {
+		layer.Visible = visible
+	}
+}
+
+func (w *World) IsLayerVisible(name RenderLayer) bool {
+	w.layerMutex.RLock()
+	defer w.layerMutex.RUnlock()
+
+	layer := w.findLayer(name)
+	return layer == nil || layer.Visible
+}
+
+func (w *World) isScreenSpace(name RenderLayer) bool {
+	w.layerMutex.RLock()
+	defer w.layerMutex.RUnlock()
+
+	layer := w.findLayer(name)
+	return layer != nil && layer.ScreenSpace
+}
+
+func (w *World) findLayer(name RenderLayer) *Layer {
+	if name == "" {
+		name = LayerWorld
+	}
+	for _, layer := range w.layers {
+		if layer.Name == name {
+			return layer
+		}
+	}
+	return nil
+}
+
+func (w *World) sortLayers() {
+	sort.SliceStable(w.layers, func(i, j int) bool {
+		return w.layers[i].Order < w.layers[j].Order
+	})
+}
+
+func (w *World) layerOrder(name RenderLayer) int {
+	layer := w.findLayer(name)
+	if layer == nil {
+		layer = w.findLayer(LayerWorld)
+	}
+	if layer == nil {
+		return 0
+	}
+	return layer.Order
+}
+
+func (w *World) nextDrawOrder() int64 {
+	return atomic.AddInt64(&w.drawOrderSeq, 1)
+}
+
+func (w *World) sortForDrawing(shapes []*Shape) []*Shape {
+	w.layerMutex.RLock()
+	defer w.layerMutex.RUnlock()
+
+	visible := shapes[:0]
+	for _, shape := range shapes {
+		layer := w.findLayer(shape.Layer)
+		if layer != nil && !layer.Visible {
+			continue
+		}
+		shape.layerOrder = w.layerOrder(shape.Layer)
+		visible = append(visible, shape)
+	}
+
+	slices.SortStableFunc(visible, compareDrawOrder)
+
+	return visible
+}
+
+func compareDrawOrder(a, b *Shape) int {
+	if a.layerOrder != b.layerOrder {
+		return cmp.Compare(a.layerOrder, b.layerOrder)
+	}
+	if a.ZIndex != b.ZIndex {
+		return cmp.Compare(a.ZIndex, b.ZIndex)
+	}
+	return cmp.Compare(a.drawOrder, b.drawOrder)
+}
+
+func (s *Shape) BringToFront() {
+	if s.world == nil {
+		return
+	}
+
+	w := s.world
+	w.mutex.RLock()
+	for _, obj := range w.Objects {
+		if obj != s && obj.Layer == s.Layer && obj.ZIndex > s.ZIndex {
+			s.ZIndex = obj.ZIndex
+		}
+	}
+	w.mutex.RUnlock()
+
+	s.drawOrder = w.nextDrawOrder()
+}
+
+func (s *Shape) SendToBack() {
+	if s.world == nil {
+		return
+	}
+
+	w := s.world
+	w.mutex.RLock()
+	for _, obj := range w.Objects {
+		if obj != s && obj.Layer == s.Layer && obj.ZIndex < s.ZIndex {
+			s.ZIndex = obj.ZIndex
+		}
+	}
+	w.mutex.RUnlock()
+
+	s.drawOrder = atomic.AddInt64(&w.drawBackSeq, -1)
+}
+
+func (s *Shape) SetLayer(layer RenderLayer) {
+	s.Layer = layer
+	if s.world != nil {
+		s.drawOrder = s.world.nextDrawOrder()
+	}
+}
@@ -6,6 +6,7 @@ type Map []string
 type MapItems map[string]func(position Vector2, width float64, height float64)
 
 type Level struct {
+	Name     string
 	Map      Map
 	MapItems MapItems
 
@@ -0,0 +1,376 @@
+package life
+
+import (
+	"image/color"
+	"math"
+
+	"github.com/ByteArena/box2d"
+	"github.com/hajimehoshi/ebiten/v2"
+)
+
+type LightType string
+
+const (
+	LightPoint LightType = "point"
+	LightSpot  LightType = "spot"
+
+	lightTextureSize      = 256
+	lightTextureCacheSize = 8
+	shadowLength          = 10000
+)
+
+var (
+	blendMultiply = ebiten.Blend{
+		BlendFactorSourceRGB:        ebiten.BlendFactorDestinationColor,
+		BlendFactorSourceAlpha:      ebiten.BlendFactorZero,
+		BlendFactorDestinationRGB:   ebiten.BlendFactorZero,
+		BlendFactorDestinationAlpha: ebiten.BlendFactorOne,
+		BlendOperationRGB:           ebiten.BlendOperationAdd,
+		BlendOperationAlpha:         ebiten.BlendOperationAdd,
+	}
+)
+
+type LightProps struct {
+	Type        LightType
+	X, Y        float64
+	Target      *Shape
+	Offset      Vector2
+	Color       color.Color
+	Radius      float64
+	Falloff     float64
+	Intensity   float64
+	Direction   float64
+	Angle       float64
+	CastShadows bool
+	Disabled    bool
+}
+
+type Light struct {
+	Type        LightType
+	X, Y        float64
+	Target      *Shape
+	Offset      Vector2
+	Color       color.Color
+	Radius      float64
+	Falloff     float64
+	Intensity   float64
+	Direction   float64
+	Angle       float64
+	CastShadows bool
+	Enabled     bool
+}
+
+func NewLight(props *LightProps) *Light {
+	if props == nil {
+		props = &LightProps{}
+	}
+
+	if props.Type == "" {
+		props.Type = LightPoint
+	}
+	if props.Color == nil {
+		props.Color = color.RGBA{255, 255, 255, 255}
+	}
+	if props.Radius == 0 {
+		props.Radius = 150
+	}
+	if props.Falloff == 0 {
+		props.Falloff = 2
+	}
+	if props.Intensity == 0 {
+		props.Intensity = 1
+	}
+	if props.Angle == 0 {
+		props.Angle = 60
+	}
+
+	return &Light{
+		Type:        props.Type,
+		X:           props.X,
+		Y:           props.Y,
+		Target:      props.Target,
+		Offset:      props.Offset,
+		Color:       props.Color,
+		Radius:      props.Radius,
+		Falloff:     props.Falloff,
+		Intensity:   props.Intensity,
+		Direction:   props.Direction,
+		Angle:       props.Angle,
+		CastShadows: props.CastShadows,
+		Enabled:     !props.Disabled,
+	}
+}
+
+func (l *Light) Position() Vector2 {
+	if l.Target != nil {
+		return Vector2{
+			X: l.Target.X + l.Target.Width/2 + l.Offset.X,
+			Y: l.Target.Y + l.Target.Height/2 + l.Offset.Y,
+		}
+	}
+	return Vector2{X: l.X + l.Offset.X, Y: l.Y + l.Offset.Y}
+}
+
+func (l *Light) SetPosition(x, y float64) {
+	l.X = x
+	l.Y = y
+}
+
+func (w *World) AddLight(light *Light) *Light {
+	w.lightMutex.Lock()
+	defer w.lightMutex.Unlock()
+
+	w.Lights = append(w.Lights, light)
+	w.LightingEnabled = true
+	return light
+}
+
+func (w *World) RemoveLight(light *Light) {
+	w.lightMutex.Lock()
+	defer w.lightMutex.Unlock()
+
+	for i, l := range w.Lights {
+		if l == light {
+			w.Lights = append(w.Lights[:i], w.Lights[i+1:]...)
+			break
+		}
+	}
+}
+
+func (w *World) ClearLights() {
+	w.lightMutex.Lock()
+	w.Lights = nil
+	w.lightMutex.Unlock()
+}
+
+func (w *World) clearTargetedLights() {
+	w.lightMutex.Lock()
+	defer w.lightMutex.Unlock()
+
+	lights := w.Lights[:0]
+	for _, light := range w.Lights {
+		if light.Target == nil || light.Target.world == w {
+			lights = append(lights, light)
+		}
+	}
+	clear(w.Lights[len(lights):])
+	w.Lights = lights
+}
+
+func (w *World) SetAmbient(darkness float64, ambientColor color.Color) {
+	w.AmbientDarkness = math.Max(0, math.Min(1, darkness))
+	if ambientColor != nil {
+		w.AmbientColor = ambientColor
+	}
+}
+
+type lightTextureCache struct {
+	textures map[float64]*ebiten.Image
+	order    []float64
+}
+
+func (w *World) lightTexture(falloff float64) *ebiten.Image {
+	// Animated falloffs would otherwise build a new texture every frame.
+	falloff = math.Round(falloff*20) / 20
+
+	cache := &w.lightTextures
+	if img, ok := cache.textures[falloff]; ok {
+		return img
+	}
+	if cache.textures == nil {
+		cache.textures = map[float64]*ebiten.Image{}
+	}
+	if len(cache.order) >= lightTextureCacheSize {
+		oldest := cache.order[0]
+		cache.order = append(cache.order[:0], cache.order[1:]...)
+		cache.textures[oldest].Deallocate()
+		delete(cache.textures, oldest)
+	}
+
+	pixels := make([]byte, lightTextureSize*lightTextureSize*4)
+	half := float64(lightTextureSize) / 2
+	for y := 0; y < lightTextureSize; y++ {
+		for x := 0; x < lightTextureSize; x++ {
+			d := math.Hypot(float64(x)+0.5-half, float64(y)+0.5-half) / half
+			v := byte(math.Pow(math.Max(0, 1-d), falloff) * 255)
+			i := (y*lightTextureSize + x) * 4
+			pixels[i], pixels[i+1], pixels[i+2], pixels[i+3] = v, v, v, v
+		}
+	}
+
+	img := ebiten.NewImage(lightTextureSize, lightTextureSize)
+	img.WritePixels(pixels)
+	cache.textures[falloff] = img
+	cache.order = append(cache.order, falloff)
+	return img
+}
+
+func (w *World) applyLighting(screen *ebiten.Image) {
+	if !w.LightingEnabled {
+		return
+	}
+
+	bounds := screen.Bounds()
+	if w.lightmap == nil || w.lightmap.Bounds() != bounds {
+		if w.lightmap != nil {
+			w.lightmap.Deallocate()
+			w.lightBuffer.Deallocate()
+		}
+		w.lightmap = ebiten.NewImage(bounds.Dx(), bounds.Dy())
+		w.lightBuffer = ebiten.NewImage(bounds.Dx(), bounds.Dy())
+	}
+
+	ambient := w.AmbientColor
+	if ambient == nil {
+		ambient = color.White
+	}
+	r, g, b, _ := straightRGBA(ambient)
+	level := 1 - w.AmbientDarkness
+	w.lightmap.Fill(color.RGBA{uint8(r * level * 255), uint8(g * level * 255), uint8(b * level * 255), 255})
+	countDrawCall()
+
+	var occluders []*Shape
+	w.mutex.RLock()
+	for _, obj := range w.Objects {
+		if obj.Occluder && obj.Body != nil && !w.isScreenSpace(obj.Layer) {
+			occluders = append(occluders, obj)
+		}
+	}
+	w.mutex.RUnlock()
+
+	w.lightMutex.RLock()
+	for _, light := range w.Lights {
+		if !light.Enabled || light.Radius <= 0 {
+			continue
+		}
+
+		w.lightBuffer.Clear()
+		w.lightBatch.Begin(w.lightBuffer)
+		w.drawLight(&w.lightBatch, light)
+		w.lightBatch.End()
+
+		if light.CastShadows && len(occluders) > 0 {
+			w.lightBatch.Begin(w.lightBuffer)
+			for _, occluder := range occluders {
+				w.drawShadow(&w.lightBatch, light, occluder)
+			}
+			w.lightBatch.End()
+		}
+
+		op := &ebiten.DrawImageOptions{}
+		op.Blend = ebiten.BlendLighter
+		w.lightmap.DrawImage(w.lightBuffer, op)
+		countDrawCall()
+	}
+	w.lightMutex.RUnlock()
+
+	op := &ebiten.DrawImageOptions{}
+	op.Blend = blendMultiply
+	screen.DrawImage(w.lightmap, op)
+	countDrawCall()
+}
+
+func (w *World) drawLight(b *Batch, light *Light) {
+	position := light.Position().Sub(w.Camera)
+	texture := w.lightTexture(light.Falloff)
+	half := float64(lightTextureSize) / 2
+
+	cr, cg, cb, ca := straightRGBA(light.Color)
+	intensity := light.Intensity * ca
+	r, g, bl, a := float32(cr*intensity), float32(cg*intensity), float32(cb*intensity), float32(intensity)
+
+	var geoM ebiten.GeoM
+	state := batchState{texture: texture, blend: ebiten.BlendLighter, filter: ebiten.FilterLinear}
+
+	if light.Type != LightSpot {
+		geoM.Translate(position.X-light.Radius, position.Y-light.Radius)
+		b.appendQuad(state, &geoM, 0, 0, light.Radius*2, light.Radius*2, 0, 0, lightTextureSize, lightTextureSize, r, g, bl, a)
+		return
+	}
+
+	points := appendArc(nil, 0, 0, light.Radius, light.Radius, light.Direction-light.Angle/2, light.Direction+light.Angle/2)
+	geoM.Translate(position.X, position.Y)
+
+	base := b.reserve(state, len(points)+1, (len(points)-1)*3)
+	b.appendVertex(&geoM, 0, 0, half, half, r, g, bl, a)
+	for _, p := range points {
+		b.appendVertex(&geoM, p.X, p.Y, half+p.X/light.Radius*half, half+p.Y/light.Radius*half, r, g, bl, a)
+	}
+	for i := 1; i < len(points); i++ {
+		b.indices = append(b.indices, base, base+uint16(i), base+uint16(i+1))
+	}
+}
+
+func (w *World) drawShadow(b *Batch, light *Light, occluder *Shape) {
+	position := light.Position().Sub(w.Camera)
+	state := batchState{blend: ebiten.BlendClear}
+
+	var geoM ebiten.GeoM
+	for _, polygon := range occluderPolygons(occluder.Body) {
+		if len(polygon) < 2 {
+			continue
+		}
+
+		for i := range polygon {
+			a := polygon[i].Sub(w.Camera)
+			c := polygon[(i+1)%len(polygon)].Sub(w.Camera)
+
+			edge := c.Sub(a)
+			normal := Vector2{X: edge.Y, Y: -edge.X}
+			toLight := position.Sub(a)
+			if normal.X*toLight.X+normal.Y*toLight.Y > 0 {
+				continue
+			}
+
+			farA := a.Add(a.Sub(position).Normalize().Mul(shadowLength))
+			farC := c.Add(c.Sub(position).Normalize().Mul(shadowLength))
+
+			base := b.reserve(state, 4, 6)
+			b.appendVertex(&geoM, a.X, a.Y, 1.5, 1.5, 1, 1, 1, 1)
+			b.appendVertex(&geoM, c.X, c.Y, 1.5, 1.5, 1, 1, 1, 1)
+			b.appendVertex(&geoM, farC.X, farC.Y, 1.5, 1.5, 1, 1, 1, 1)
+			b.appendVertex(&geoM, farA.X, farA.Y, 1.5, 1.5, 1, 1, 1, 1)
+			b.indices = append(b.indices, base, base+1, base+2, base, base+2, base+3)
+		}
+	}
+}
+
+func occluderPolygons(body *box2d.B2Body) [][]Vector2 {
+	if body == nil {
+		return nil
+	}
+
+	transform := body.GetTransform()
+	var polygons [][]Vector2
+
+	for fixture := body.GetFixtureList(); fixture != nil; fixture = fixture.GetNext() {
+		if fixture.IsSensor() {
+			continue
+		}
+
+		switch shape := fixture.GetShape().(type) {
+		case *box2d.B2PolygonShape:
+			polygon := make([]Vector2, 0, shape.M_count)
+			for i := 0; i < shape.M_count; i++ {
+				v := box2d.B2TransformVec2Mul(transform, shape.M_vertices[i])
+				polygon = append(polygon, Vector2{X: MetersToPixels(v.X), Y: MetersToPixels(v.Y)})
+			}
+			polygons = append(polygons, polygon)
+
+		case *box2d.B2CircleShape:
+			center := box2d.B2TransformVec2Mul(transform, shape.M_p)
+			radius := MetersToPixels(shape.M_radius)
+			cx, cy := MetersToPixels(center.X), MetersToPixels(center.Y)
+
+			const segments = 16
+			polygon := make([]Vector2, 0, segments)
+			for i := 0; i < segments; i++ {
+				angle := float64(i) / segments * 2 * math.Pi
+				polygon = append(polygon, Vector2{X: cx + math.Cos(angle)*radius, Y: cy + math.Sin(angle)*radius})
+			}
+			polygons = append(polygons, polygon)
+		}
+	}
+
+	return polygons
+}
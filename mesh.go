@@ -0,0 +1,324 @@
+package life
+
+import (
+	"image/color"
+	"math"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/vector"
+)
+
+type LineCap = vector.LineCap
+type LineJoin = vector.LineJoin
+
+const (
+	CapButt   = vector.LineCapButt
+	CapRound  = vector.LineCapRound
+	CapSquare = vector.LineCapSquare
+
+	JoinMiter = vector.LineJoinMiter
+	JoinBevel = vector.LineJoinBevel
+	JoinRound = vector.LineJoinRound
+)
+
+type Stroke struct {
+	Width      float64
+	Color      color.Color
+	Cap        LineCap
+	Join       LineJoin
+	MiterLimit float64
+	Dash       []float64
+	DashOffset float64
+}
+
+type meshKey struct {
+	shapeType    ShapeType
+	width        float64
+	height       float64
+	radius       float64
+	cornerRadius float64
+	arcStart     float64
+	arcEnd       float64
+	borderWidth  float64
+	strokeWidth  float64
+	cap          LineCap
+	join         LineJoin
+	miterLimit   float64
+	dashOffset   float64
+}
+
+type mesh struct {
+	vertices []ebiten.Vertex
+	indices  []uint16
+}
+
+type shapeMesh struct {
+	key    meshKey
+	dash   []float64
+	fill   mesh
+	stroke mesh
+	border mesh
+	points []Vector2
+}
+
+func (m *shapeMesh) matches(key meshKey, dash []float64) bool {
+	if m.key != key || len(m.dash) != len(dash) {
+		return false
+	}
+	for i := range dash {
+		if m.dash[i] != dash[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func (s *Shape) meshKey(width, height float64) meshKey {
+	key := meshKey{
+		shapeType:    s.Type,
+		width:        width,
+		height:       height,
+		radius:       s.Radius,
+		cornerRadius: s.CornerRadius,
+		arcStart:     s.ArcStart,
+		arcEnd:       s.ArcEnd,
+	}
+	if s.Border != nil {
+		key.borderWidth = s.Border.Width
+	}
+	if s.Stroke != nil {
+		key.strokeWidth = s.Stroke.Width
+		key.cap = s.Stroke.Cap
+		key.join = s.Stroke.Join
+		key.miterLimit = s.Stroke.MiterLimit
+		key.dashOffset = s.Stroke.DashOffset
+	}
+	return key
+}
+
+func (s *Shape) getMesh(width, height float64) *shapeMesh {
+	key := s.meshKey(width, height)
+	var dash []float64
+	if s.Stroke != nil {
+		dash = s.Stroke.Dash
+	}
+
+	if s.mesh != nil && s.mesh.matches(key, dash) {
+		return s.mesh
+	}
+
+	if s.mesh == nil {
+		s.mesh = &shapeMesh{}
+	}
+	m := s.mesh
+	m.key = key
+	m.dash = append(m.dash[:0], dash...)
+	m.build()
+	return m
+}
+
+func (m *shapeMesh) build() {
+	key := m.key
+	m.fill.vertices, m.fill.indices = m.fill.vertices[:0], m.fill.indices[:0]
+	m.stroke.vertices, m.stroke.indices = m.stroke.vertices[:0], m.stroke.indices[:0]
+	m.border.vertices, m.border.indices = m.border.vertices[:0], m.border.indices[:0]
+
+	closed := key.shapeType != ShapeLine
+	m.points = appendOutline(m.points[:0], key, 0, false)
+
+	if key.shapeType != ShapeLine || key.strokeWidth <= 0 {
+		outline := m.points
+		if key.shapeType == ShapeLine {
+			outline = appendRoundedRect(nil, 0, 0, key.width, key.height, 0)
+		}
+		var path vector.Path
+		appendPolyline(&path, outline, true)
+		m.fill.vertices, m.fill.indices = path.AppendVerticesAndIndicesForFilling(m.fill.vertices, m.fill.indices)
+	}
+
+	if key.strokeWidth > 0 {
+		strokePoints := m.points
+		if key.shapeType == ShapeArc {
+			strokePoints = appendArc(nil, key.radius, key.radius, key.radius, key.radius, key.arcStart, key.arcEnd)
+			closed = false
+		}
+
+		var path vector.Path
+		if len(m.dash) > 0 {
+			appendDashes(&path, strokePoints, closed, m.dash, key.dashOffset)
+		} else {
+			appendPolyline(&path, strokePoints, closed)
+		}
+		m.stroke.vertices, m.stroke.indices = path.AppendVerticesAndIndicesForStroke(m.stroke.vertices, m.stroke.indices, &vector.StrokeOptions{
+			Width:      float32(key.strokeWidth),
+			LineCap:    key.cap,
+			LineJoin:   key.join,
+			MiterLimit: float32(key.miterLimit),
+		})
+	}
+
+	if key.borderWidth > 0 {
+		var path vector.Path
+		appendPolyline(&path, appendOutline(nil, key, key.borderWidth/2, true), true)
+		m.border.vertices, m.border.indices = path.AppendVerticesAndIndicesForStroke(m.border.vertices, m.border.indices, &vector.StrokeOptions{
+			Width:      float32(key.borderWidth),
+			LineJoin:   vector.LineJoinMiter,
+			MiterLimit: 10,
+		})
+	}
+}
+
+func appendOutline(points []Vector2, key meshKey, grow float64, border bool) []Vector2 {
+	switch key.shapeType {
+	case ShapeCircle, ShapeDot:
+		r := key.width / 2
+		return appendEllipse(points, r, r, r+grow, r+grow)
+	case ShapeEllipse:
+		return appendEllipse(points, key.width/2, key.height/2, key.width/2+grow, key.height/2+grow)
+	case ShapeArc:
+		r := key.radius
+		points = append(points, Vector2{X: r, Y: r})
+		return appendArc(points, r, r, r+grow, r+grow, key.arcStart, key.arcEnd)
+	case ShapeLine:
+		if !border {
+			return append(points, Vector2{X: 0, Y: key.height / 2}, Vector2{X: key.width, Y: key.height / 2})
+		}
+	}
+
+	radius := key.cornerRadius
+	if radius > 0 {
+		radius += grow
+	}
+	return appendRoundedRect(points, -grow, -grow, key.width+grow*2, key.height+grow*2, radius)
+}
+
+func appendRoundedRect(points []Vector2, x, y, width, height, radius float64) []Vector2 {
+	radius = math.Min(radius, math.Min(width, height)/2)
+	if radius <= 0 {
+		return append(points,
+			Vector2{X: x, Y: y},
+			Vector2{X: x + width, Y: y},
+			Vector2{X: x + width, Y: y + height},
+			Vector2{X: x, Y: y + height},
+		)
+	}
+
+	points = appendArc(points, x+width-radius, y+radius, radius, radius, -90, 0)
+	points = appendArc(points, x+width-radius, y+height-radius, radius, radius, 0, 90)
+	points = appendArc(points, x+radius, y+height-radius, radius, radius, 90, 180)
+	return appendArc(points, x+radius, y+radius, radius, radius, 180, 270)
+}
+
+func appendEllipse(points []Vector2, cx, cy, rx, ry float64) []Vector2 {
+	segments := circleSegments(math.Max(rx, ry))
+	step := 2 * math.Pi / float64(segments)
+	for i := 0; i < segments; i++ {
+		angle := float64(i) * step
+		points = append(points, Vector2{X: cx + math.Cos(angle)*rx, Y: cy + math.Sin(angle)*ry})
+	}
+	return points
+}
+
+func appendArc(points []Vector2, cx, cy, rx, ry, startDeg, endDeg float64) []Vector2 {
+	start, end := startDeg*Deg, endDeg*Deg
+	sweep := end - start
+	segments := int(math.Ceil(float64(circleSegments(math.Max(rx, ry))) * math.Abs(sweep) / (2 * math.Pi)))
+	if segments < 1 {
+		segments = 1
+	}
+	for i := 0; i <= segments; i++ {
+		angle := start + sweep*float64(i)/float64(segments)
+		points = append(points, Vector2{X: cx + math.Cos(angle)*rx, Y: cy + math.Sin(angle)*ry})
+	}
+	return points
+}
+
+func appendPolyline(path *vector.Path, points []Vector2, closed bool) {
+	if len(points) == 0 {
+		return
+	}
+	path.MoveTo(float32(points[0].X), float32(points[0].Y))
+	for _, p := range points[1:] {
+		path.LineTo(float32(p.X), float32(p.Y))
+	}
+	if closed {
+		path.Close()
+	}
+}
+
+func appendDashes(path *vector.Path, points []Vector2, closed bool, dash []float64, offset float64) {
+	total := 0.0
+	for _, d := range dash {
+		total += math.Abs(d)
+	}
+	if total <= 0 || len(points) < 2 {
+		appendPolyline(path, points, closed)
+		return
+	}
+
+	index := 0
+	remaining := math.Mod(offset, total)
+	if remaining < 0 {
+		remaining += total
+	}
+	for remaining >= math.Abs(dash[index]) {
+		remaining -= math.Abs(dash[index])
+		index = (index + 1) % len(dash)
+	}
+	remaining = math.Abs(dash[index]) - remaining
+	on := index%2 == 0
+
+	count := len(points)
+	if closed {
+		count++
+	}
+
+	if on {
+		path.MoveTo(float32(points[0].X), float32(points[0].Y))
+	}
+
+	for i := 1; i < count; i++ {
+		from := points[i-1]
+		to := points[i%len(points)]
+		segment := to.Sub(from)
+		length := segment.Length()
+		direction := segment.Normalize()
+
+		for length > 0 {
+			step := math.Min(remaining, length)
+			from = from.Add(direction.Mul(step))
+			length -= step
+			remaining -= step
+
+			if on {
+				path.LineTo(float32(from.X), float32(from.Y))
+			}
+
+			if remaining <= 0 {
+				index = (index + 1) % len(dash)
+				remaining = math.Abs(dash[index])
+				on = !on
+				if on {
+					path.MoveTo(float32(from.X), float32(from.Y))
+				}
+			}
+		}
+	}
+}
+
+func (s *Shape) drawMesh(b *Batch, width, height float64) {
+	var geoM ebiten.GeoM
+	s.applyTransformations(&geoM, width, height)
+
+	m := s.getMesh(width, height)
+
+	if s.Border != nil && s.Border.Width > 0 {
+		b.DrawMesh(m.border.vertices, m.border.indices, &geoM, s.Border.Background, s.Opacity, true)
+	}
+
+	s.drawPattern(b, &geoM, m, width, height)
+
+	if s.Stroke != nil && s.Stroke.Width > 0 {
+		b.DrawMesh(m.stroke.vertices, m.stroke.indices, &geoM, s.Stroke.Color, s.Opacity, true)
+	}
+}
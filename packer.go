@@ -0,0 +1,293 @@
+package life
+
+import (
+	"embed"
+	"fmt"
+	"image"
+	"image/draw"
+	"math"
+	"os"
+	"slices"
+	"sync"
+
+	"github.com/hajimehoshi/ebiten/v2"
+)
+
+var (
+	textureParentMutex sync.RWMutex
+	textureParents     = map[*ebiten.Image]*ebiten.Image{}
+)
+
+func registerSubImage(sub, parent *ebiten.Image) {
+	if sub == nil || parent == nil || sub == parent {
+		return
+	}
+	textureParentMutex.Lock()
+	if root, ok := textureParents[parent]; ok {
+		parent = root
+	}
+	textureParents[sub] = parent
+	textureParentMutex.Unlock()
+}
+
+func textureParent(texture *ebiten.Image) *ebiten.Image {
+	textureParentMutex.RLock()
+	parent := textureParents[texture]
+	textureParentMutex.RUnlock()
+	return parent
+}
+
+type AtlasBuilderProps struct {
+	PageSize int
+	Padding  *int
+}
+
+type atlasSource struct {
+	name   string
+	img    image.Image
+	ebiten *ebiten.Image
+	width  int
+	height int
+	page   int
+	rect   image.Rectangle
+}
+
+type AtlasBuilder struct {
+	PageSize int
+	Padding  int
+
+	sources  []*atlasSource
+	usedArea int
+	pageArea int
+}
+
+func NewAtlasBuilder(props *AtlasBuilderProps) *AtlasBuilder {
+	if props == nil {
+		props = &AtlasBuilderProps{}
+	}
+
+	if props.PageSize == 0 {
+		props.PageSize = 2048
+	}
+
+	padding := 2
+	if props.Padding != nil {
+		padding = max(0, *props.Padding)
+	}
+
+	return &AtlasBuilder{
+		PageSize: props.PageSize,
+		Padding:  padding,
+	}
+}
+
+func (b *AtlasBuilder) Add(name string, img image.Image) *AtlasBuilder {
+	bounds := img.Bounds()
+	b.sources = append(b.sources, &atlasSource{name: name, img: img, width: bounds.Dx(), height: bounds.Dy()})
+	return b
+}
+
+func (b *AtlasBuilder) AddImage(name string, img *ebiten.Image) *AtlasBuilder {
+	bounds := img.Bounds()
+	b.sources = append(b.sources, &atlasSource{name: name, ebiten: img, width: bounds.Dx(), height: bounds.Dy()})
+	return b
+}
+
+func (b *AtlasBuilder) AddFile(name, path string) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("failed to open %s: %w", path, err)
+	}
+	defer file.Close()
+
+	img, _, err := image.Decode(file)
+	if err != nil {
+		return fmt.Errorf("failed to decode %s: %w", path, err)
+	}
+	b.Add(name, img)
+	return nil
+}
+
+func (b *AtlasBuilder) AddFromFS(name string, fs embed.FS, path string) error {
+	file, err := fs.Open(path)
+	if err != nil {
+		return fmt.Errorf("failed to open %s: %w", path, err)
+	}
+	defer file.Close()
+
+	img, _, err := image.Decode(file)
+	if err != nil {
+		return fmt.Errorf("failed to decode %s: %w", path, err)
+	}
+	b.Add(name, img)
+	return nil
+}
+
+func (b *AtlasBuilder) Efficiency() float64 {
+	if b.pageArea == 0 {
+		return 0
+	}
+	return float64(b.usedArea) / float64(b.pageArea)
+}
+
+func (b *AtlasBuilder) Build() (*TextureAtlas, error) {
+	order := slices.Clone(b.sources)
+	slices.SortStableFunc(order, func(x, y *atlasSource) int {
+		if d := max(y.width, y.height) - max(x.width, x.height); d != 0 {
+			return d
+		}
+		return y.width*y.height - x.width*x.height
+	})
+
+	var bins []*maxRects
+	for _, source := range order {
+		if source.width == 0 || source.height == 0 {
+			return nil, fmt.Errorf("atlas image %s is empty", source.name)
+		}
+
+		w, h := source.width+b.Padding, source.height+b.Padding
+		placed := false
+		for i, bin := range bins {
+			if rect, ok := bin.insert(w, h); ok {
+				source.page, source.rect, placed = i, rect, true
+				break
+			}
+		}
+		if placed {
+			continue
+		}
+
+		size := b.PageSize
+		if w > size || h > size {
+			size = max(w, h)
+		}
+		bin := newMaxRects(size, size)
+		rect, _ := bin.insert(w, h)
+		source.page, source.rect = len(bins), rect
+		bins = append(bins, bin)
+	}
+
+	atlas := newTextureAtlas()
+	b.usedArea, b.pageArea = 0, 0
+
+	pixels := make([]*image.RGBA, len(bins))
+	for i, bin := range bins {
+		pixels[i] = image.NewRGBA(image.Rect(0, 0, bin.extent.X, bin.extent.Y))
+		b.pageArea += bin.extent.X * bin.extent.Y
+	}
+	for _, source := range b.sources {
+		if source.img == nil {
+			continue
+		}
+		dst := image.Rect(source.rect.Min.X, source.rect.Min.Y, source.rect.Min.X+source.width, source.rect.Min.Y+source.height)
+		draw.Draw(pixels[source.page], dst, source.img, source.img.Bounds().Min, draw.Src)
+	}
+
+	for _, page := range pixels {
+		atlas.Pages = append(atlas.Pages, ebiten.NewImageFromImage(page))
+	}
+
+	for _, source := range b.sources {
+		page := atlas.Pages[source.page]
+		rect := image.Rect(source.rect.Min.X, source.rect.Min.Y, source.rect.Min.X+source.width, source.rect.Min.Y+source.height)
+
+		if source.ebiten != nil {
+			op := &ebiten.DrawImageOptions{}
+			op.GeoM.Translate(float64(rect.Min.X), float64(rect.Min.Y))
+			op.Blend = ebiten.BlendCopy
+			page.DrawImage(source.ebiten, op)
+		}
+
+		sub := page.SubImage(rect).(*ebiten.Image)
+		if b.Padding > 0 {
+			registerSubImage(sub, page)
+		}
+
+		b.usedArea += source.width * source.height
+		atlas.add(&AtlasRegion{
+			Name:         source.name,
+			Index:        -1,
+			Page:         source.page,
+			Image:        sub,
+			Bounds:       rect,
+			OriginalSize: rect.Size(),
+		})
+	}
+
+	return atlas, nil
+}
+
+type maxRects struct {
+	width  int
+	height int
+	free   []image.Rectangle
+	extent image.Point
+}
+
+func newMaxRects(width, height int) *maxRects {
+	return &maxRects{
+		width:  width,
+		height: height,
+		free:   []image.Rectangle{image.Rect(0, 0, width, height)},
+	}
+}
+
+func (m *maxRects) insert(width, height int) (image.Rectangle, bool) {
+	bestShort, bestLong := math.MaxInt, math.MaxInt
+	var node image.Rectangle
+	found := false
+
+	for _, free := range m.free {
+		if free.Dx() < width || free.Dy() < height {
+			continue
+		}
+		leftX, leftY := free.Dx()-width, free.Dy()-height
+		short, long := min(leftX, leftY), max(leftX, leftY)
+		if short < bestShort || (short == bestShort && long < bestLong) {
+			bestShort, bestLong = short, long
+			node = image.Rect(free.Min.X, free.Min.Y, free.Min.X+width, free.Min.Y+height)
+			found = true
+		}
+	}
+	if !found {
+		return image.Rectangle{}, false
+	}
+
+	var next []image.Rectangle
+	for _, free := range m.free {
+		if !free.Overlaps(node) {
+			next = append(next, free)
+			continue
+		}
+		if node.Min.X > free.Min.X {
+			next = append(next, image.Rect(free.Min.X, free.Min.Y, node.Min.X, free.Max.Y))
+		}
+		if node.Max.X < free.Max.X {
+			next = append(next, image.Rect(node.Max.X, free.Min.Y, free.Max.X, free.Max.Y))
+		}
+		if node.Min.Y > free.Min.Y {
+			next = append(next, image.Rect(free.Min.X, free.Min.Y, free.Max.X, node.Min.Y))
+		}
+		if node.Max.Y < free.Max.Y {
+			next = append(next, image.Rect(free.Min.X, node.Max.Y, free.Max.X, free.Max.Y))
+		}
+	}
+
+	m.free = m.free[:0]
+	for i, a := range next {
+		contained := false
+		for j, b := range next {
+			if i != j && a.In(b) && (a != b || i > j) {
+				contained = true
+				break
+			}
+		}
+		if !contained {
+			m.free = append(m.free, a)
+		}
+	}
+
+	m.extent.X = min(m.width, max(m.extent.X, node.Max.X))
+	m.extent.Y = min(m.height, max(m.extent.Y, node.Max.Y))
+	return node, true
+}
@@ -0,0 +1,102 @@
+package life
+
+import (
+	"fmt"
+	"image"
+	"math/rand"
+	"testing"
+)
+
+func TestMaxRectsInsertDoesNotOverlap(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	bin := newMaxRects(256, 256)
+
+	var placed []image.Rectangle
+	for i := 0; i < 500; i++ {
+		width, height := 4+r.Intn(29), 4+r.Intn(29)
+		rect, ok := bin.insert(width, height)
+		if !ok {
+			continue
+		}
+
+		if rect.Dx() != width || rect.Dy() != height {
+			t.Fatalf("insert(%d, %d) returned %v", width, height, rect)
+		}
+		if !rect.In(image.Rect(0, 0, 256, 256)) {
+			t.Fatalf("insert(%d, %d) returned %v outside the bin", width, height, rect)
+		}
+		for _, other := range placed {
+			if rect.Overlaps(other) {
+				t.Fatalf("insert(%d, %d) returned %v overlapping %v", width, height, rect, other)
+			}
+		}
+		placed = append(placed, rect)
+	}
+
+	if len(placed) == 0 {
+		t.Fatal("nothing was placed")
+	}
+}
+
+func TestAtlasBuilderRegions(t *testing.T) {
+	padding := 2
+	builder := NewAtlasBuilder(&AtlasBuilderProps{PageSize: 128, Padding: &padding})
+
+	r := rand.New(rand.NewSource(2))
+	sizes := map[string]image.Point{}
+	for i := 0; i < 40; i++ {
+		name := fmt.Sprintf("image%d", i)
+		size := image.Pt(4+r.Intn(29), 4+r.Intn(29))
+		sizes[name] = size
+		builder.Add(name, image.NewRGBA(image.Rectangle{Max: size}))
+	}
+
+	atlas, err := builder.Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	pages := map[int][]image.Rectangle{}
+	for name, size := range sizes {
+		region := atlas.GetRegion(name)
+		if region == nil {
+			t.Fatalf("region %s is missing", name)
+		}
+		if region.Bounds.Size() != size {
+			t.Errorf("region %s has bounds %v, want size %v", name, region.Bounds, size)
+		}
+		if region.Image.Bounds() != region.Bounds {
+			t.Errorf("region %s image has bounds %v, want %v", name, region.Image.Bounds(), region.Bounds)
+		}
+
+		padded := image.Rectangle{Min: region.Bounds.Min, Max: region.Bounds.Max.Add(image.Pt(padding, padding))}
+		for _, other := range pages[region.Page] {
+			if padded.Overlaps(other) {
+				t.Errorf("region %s at %v overlaps %v on page %d", name, padded, other, region.Page)
+			}
+		}
+		pages[region.Page] = append(pages[region.Page], padded)
+	}
+}
+
+func TestAtlasBuilderEfficiency(t *testing.T) {
+	padding := 0
+	builder := NewAtlasBuilder(&AtlasBuilderProps{PageSize: 512, Padding: &padding})
+
+	r := rand.New(rand.NewSource(1))
+	for i := 0; i < 200; i++ {
+		builder.Add(fmt.Sprintf("image%d", i), image.NewRGBA(image.Rect(0, 0, 8+r.Intn(57), 8+r.Intn(57))))
+	}
+
+	atlas, err := builder.Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(atlas.Pages) != 1 {
+		t.Errorf("packed into %d pages, want 1", len(atlas.Pages))
+	}
+	if efficiency := builder.Efficiency(); efficiency < 0.85 {
+		t.Errorf("efficiency is %.2f, want at least 0.85", efficiency)
+	}
+}
@@ -0,0 +1,132 @@
+package life
+
+import (
+	"math"
+
+	"github.com/hajimehoshi/ebiten/v2"
+)
+
+type ParallaxLayer struct {
+	Image   *ebiten.Image
+	FactorX float64
+	FactorY float64
+	Repeat  bool
+	SpeedX  float64
+	SpeedY  float64
+	OffsetX float64
+	OffsetY float64
+	Scale   float64
+	Opacity float64
+	Visible bool
+
+	scrollX, scrollY float64
+}
+
+func (w *World) AddParallaxLayer(image *ebiten.Image, factorX, factorY float64, repeat bool) *ParallaxLayer {
+	layer := &ParallaxLayer{
+		Image:   image,
+		FactorX: factorX,
+		FactorY: factorY,
+		Repeat:  repeat,
+		Scale:   1,
+		Opacity: 1,
+		Visible: true,
+	}
+
+	w.parallaxMutex.Lock()
+	w.ParallaxLayers = append(w.ParallaxLayers, layer)
+	w.parallaxMutex.Unlock()
+
+	return layer
+}
+
+func (w *World) RemoveParallaxLayer(layer *ParallaxLayer) {
+	w.parallaxMutex.Lock()
+	defer w.parallaxMutex.Unlock()
+
+	for i, l := range w.ParallaxLayers {
+		if l == layer {
+			w.ParallaxLayers = append(w.ParallaxLayers[:i], w.ParallaxLayers[i+1:]...)
+			break
+		}
+	}
+}
+
+func (w *World) ClearParallaxLayers() {
+	w.parallaxMutex.Lock()
+	w.ParallaxLayers = nil
+	w.parallaxMutex.Unlock()
+}
+
+func (l *ParallaxLayer) SetSpeed(speedX, speedY float64) *ParallaxLayer {
+	l.SpeedX = speedX
+	l.SpeedY = speedY
+	return l
+}
+
+func (l *ParallaxLayer) update(delta float64) {
+	l.scrollX += l.SpeedX * delta
+	l.scrollY += l.SpeedY * delta
+}
+
+func (l *ParallaxLayer) draw(b *Batch, camera Vector2) {
+	if !l.Visible || l.Image == nil || l.Opacity <= 0 {
+		return
+	}
+
+	bounds := l.Image.Bounds()
+	tileW := float64(bounds.Dx()) * l.Scale
+	tileH := float64(bounds.Dy()) * l.Scale
+	if tileW <= 0 || tileH <= 0 {
+		return
+	}
+
+	originX := l.OffsetX + l.scrollX - camera.X*l.FactorX
+	originY := l.OffsetY + l.scrollY - camera.Y*l.FactorY
+
+	screenW := float64(b.target.Bounds().Dx())
+	screenH := float64(b.target.Bounds().Dy())
+
+	startX, endX := originX, originX
+	startY, endY := originY, originY
+	if l.Repeat {
+		startX = math.Mod(originX, tileW)
+		if startX > 0 {
+			startX -= tileW
+		}
+		startY = math.Mod(originY, tileH)
+		if startY > 0 {
+			startY -= tileH
+		}
+		endX = screenW
+		endY = screenH
+	}
+
+	var geoM ebiten.GeoM
+	for y := startY; y <= endY; y += tileH {
+		for x := startX; x <= endX; x += tileW {
+			geoM.Reset()
+			geoM.Scale(l.Scale, l.Scale)
+			geoM.Translate(x, y)
+			b.DrawImage(l.Image, &geoM, l.Opacity)
+		}
+	}
+}
+
+func (w *World) updateParallax(delta float64) {
+	w.parallaxMutex.RLock()
+	defer w.parallaxMutex.RUnlock()
+
+	for _, layer := range w.ParallaxLayers {
+		layer.update(delta)
+	}
+}
+
+func (w *World) drawParallax(b *Batch) {
+	w.parallaxMutex.RLock()
+	defer w.parallaxMutex.RUnlock()
+
+	for _, layer := range w.ParallaxLayers {
+		layer.draw(b, w.Camera)
+	}
+}
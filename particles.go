@@ -0,0 +1,447 @@
+package life
+
+import (
+	"cmp"
+	"image/color"
+	"math"
+	"math/rand"
+	"slices"
+
+	"github.com/hajimehoshi/ebiten/v2"
+)
+
+type Particle struct {
+	Position Vector2
+	Velocity Vector2
+	Rotation float64
+	Spin     float64
+	Age      float64
+	Lifetime float64
+}
+
+// ParticleZero marks emitter props whose zero value is meant literally rather
+// than replaced by the default.
+type ParticleZero uint8
+
+const (
+	ZeroSpeed ParticleZero = 1 << iota
+	ZeroSpread
+	ZeroStartSize
+	ZeroEndSize
+	ZeroStartOpacity
+)
+
+// ParticleEmitterProps defaults Speed to 60, Spread to 360, StartSize to 4,
+// EndSize to StartSize and StartOpacity to 1 unless KeepZero says otherwise.
+type ParticleEmitterProps struct {
+	X, Y   float64
+	Target *Shape
+	Offset Vector2
+
+	Rate         float64
+	MaxParticles int
+	Emitting     bool
+	AutoRemove   bool
+
+	Lifetime         float64
+	LifetimeVariance float64
+	Speed            float64
+	SpeedVariance    float64
+	Direction        float64
+	Spread           float64
+	Spin             float64
+	SpinVariance     float64
+	Gravity          Vector2
+	Damping          float64
+
+	StartColor   color.Color
+	EndColor     color.Color
+	StartSize    float64
+	EndSize      float64
+	StartOpacity float64
+	EndOpacity   float64
+	KeepZero     ParticleZero
+
+	Frames   []*ebiten.Image
+	Additive bool
+
+	Layer  RenderLayer
+	ZIndex int
+}
+
+type ParticleEmitter struct {
+	X, Y   float64
+	Target *Shape
+	Offset Vector2
+
+	Rate       float64
+	Emitting   bool
+	AutoRemove bool
+
+	Lifetime         float64
+	LifetimeVariance float64
+	Speed            float64
+	SpeedVariance    float64
+	Direction        float64
+	Spread           float64
+	Spin             float64
+	SpinVariance     float64
+	Gravity          Vector2
+	Damping          float64
+
+	StartColor   color.Color
+	EndColor     color.Color
+	StartSize    float64
+	EndSize      float64
+	StartOpacity float64
+	EndOpacity   float64
+
+	Frames   []*ebiten.Image
+	Additive bool
+
+	Layer  RenderLayer
+	ZIndex int
+
+	particles      []Particle
+	active         int
+	accumulator    float64
+	layerOrder     int
+	targetAttached bool
+	world          *World
+}
+
+func NewParticleEmitter(props *ParticleEmitterProps) *ParticleEmitter {
+	if props == nil {
+		props = &ParticleEmitterProps{}
+	}
+
+	if props.MaxParticles == 0 {
+		props.MaxParticles = 256
+	}
+	if props.Lifetime == 0 {
+		props.Lifetime = 1
+	}
+	if props.StartColor == nil {
+		props.StartColor = color.RGBA{255, 255, 255, 255}
+	}
+	if props.EndColor == nil {
+		props.EndColor = props.StartColor
+	}
+	if props.Speed == 0 && props.KeepZero&ZeroSpeed == 0 {
+		props.Speed = 60
+	}
+	if props.Spread == 0 && props.KeepZero&ZeroSpread == 0 {
+		props.Spread = 360
+	}
+	if props.StartSize == 0 && props.KeepZero&ZeroStartSize == 0 {
+		props.StartSize = 4
+	}
+	if props.EndSize == 0 && props.KeepZero&ZeroEndSize == 0 {
+		props.EndSize = props.StartSize
+	}
+	if props.StartOpacity == 0 && props.KeepZero&ZeroStartOpacity == 0 {
+		props.StartOpacity = 1
+	}
+	if props.Layer == "" {
+		props.Layer = LayerWorld
+	}
+
+	return &ParticleEmitter{
+		X:                props.X,
+		Y:                props.Y,
+		Target:           props.Target,
+		Offset:           props.Offset,
+		Rate:             props.Rate,
+		Emitting:         props.Emitting,
+		AutoRemove:       props.AutoRemove,
+		Lifetime:         props.Lifetime,
+		LifetimeVariance: props.LifetimeVariance,
+		Speed:            props.Speed,
+		SpeedVariance:    props.SpeedVariance,
+		Direction:        props.Direction,
+		Spread:           props.Spread,
+		Spin:             props.Spin,
+		SpinVariance:     props.SpinVariance,
+		Gravity:          props.Gravity,
+		Damping:          props.Damping,
+		StartColor:       props.StartColor,
+		EndColor:         props.EndColor,
+		StartSize:        props.StartSize,
+		EndSize:          props.EndSize,
+		StartOpacity:     props.StartOpacity,
+		EndOpacity:       props.EndOpacity,
+		Frames:           props.Frames,
+		Additive:         props.Additive,
+		Layer:            props.Layer,
+		ZIndex:           props.ZIndex,
+		particles:        make([]Particle, props.MaxParticles),
+	}
+}
+
+func (e *ParticleEmitter) Start() *ParticleEmitter {
+	e.Emitting = true
+	return e
+}
+
+func (e *ParticleEmitter) Stop() *ParticleEmitter {
+	e.Emitting = false
+	e.accumulator = 0
+	return e
+}
+
+func (e *ParticleEmitter) Clear() {
+	e.active = 0
+}
+
+func (e *ParticleEmitter) ActiveCount() int {
+	return e.active
+}
+
+func (e *ParticleEmitter) Position() Vector2 {
+	if e.Target != nil {
+		return Vector2{
+			X: e.Target.X + e.Target.Width/2 + e.Offset.X,
+			Y: e.Target.Y + e.Target.Height/2 + e.Offset.Y,
+		}
+	}
+	return Vector2{X: e.X + e.Offset.X, Y: e.Y + e.Offset.Y}
+}
+
+func (e *ParticleEmitter) SetPosition(x, y float64) {
+	e.X = x
+	e.Y = y
+}
+
+func (e *ParticleEmitter) Burst(count int) {
+	origin := e.Position()
+	for i := 0; i < count; i++ {
+		e.spawn(origin)
+	}
+}
+
+func (e *ParticleEmitter) spawn(origin Vector2) {
+	if e.active >= len(e.particles) {
+		return
+	}
+
+	angle := (e.Direction + (rand.Float64()-0.5)*e.Spread) * Deg
+	speed := e.Speed + (rand.Float64()*2-1)*e.SpeedVariance
+
+	p := &e.particles[e.active]
+	*p = Particle{
+		Position: origin,
+		Velocity: Vector2{X: math.Cos(angle) * speed, Y: math.Sin(angle) * speed},
+		Spin:     e.Spin + (rand.Float64()*2-1)*e.SpinVariance,
+		Lifetime: math.Max(0.01, e.Lifetime+(rand.Float64()*2-1)*e.LifetimeVariance),
+	}
+	e.active++
+}
+
+func (e *ParticleEmitter) Update(delta float64) {
+	if e.Target != nil {
+		if e.Target.world != nil {
+			e.targetAttached = true
+		} else if e.targetAttached {
+			e.Emitting = false
+		}
+	}
+
+	if e.Emitting && e.Rate > 0 {
+		e.accumulator += delta * e.Rate
+		origin := e.Position()
+		for e.accumulator >= 1 {
+			e.accumulator--
+			e.spawn(origin)
+		}
+	}
+
+	damping := 1.0
+	if e.Damping > 0 {
+		damping = math.Max(0, 1-e.Damping*delta)
+	}
+
+	for i := 0; i < e.active; {
+		p := &e.particles[i]
+		p.Age += delta
+		if p.Age >= p.Lifetime {
+			e.active--
+			e.particles[i] = e.particles[e.active]
+			continue
+		}
+
+		p.Velocity = p.Velocity.Add(e.Gravity.Mul(delta)).Mul(damping)
+		p.Position = p.Position.Add(p.Velocity.Mul(delta))
+		p.Rotation += p.Spin * Deg * delta
+		i++
+	}
+}
+
+func (e *ParticleEmitter) Finished() bool {
+	return !e.Emitting && e.active == 0
+}
+
+func (e *ParticleEmitter) Draw(b *Batch, camera Vector2) {
+	state := batchState{filter: b.Filter}
+	if e.Additive {
+		state.blend = ebiten.BlendLighter
+	}
+
+	sr, sg, sb, sa := straightRGBA(e.StartColor)
+	er, eg, eb, ea := straightRGBA(e.EndColor)
+
+	var geoM ebiten.GeoM
+	for i := 0; i < e.active; i++ {
+		p := &e.particles[i]
+		t := p.Age / p.Lifetime
+
+		size := lerp(e.StartSize, e.EndSize, t)
+		opacity := lerp(e.StartOpacity, e.EndOpacity, t) * lerp(sa, ea, t)
+		if size <= 0 || opacity <= 0 {
+			continue
+		}
+
+		r := float32(lerp(sr, er, t) * opacity)
+		g := float32(lerp(sg, eg, t) * opacity)
+		bl := float32(lerp(sb, eb, t) * opacity)
+		a := float32(opacity)
+
+		geoM.Reset()
+		geoM.Translate(-size/2, -size/2)
+		geoM.Rotate(p.Rotation)
+		geoM.Translate(p.Position.X-camera.X, p.Position.Y-camera.Y)
+
+		if len(e.Frames) == 0 {
+			b.appendQuad(state, &geoM, 0, 0, size, size, 1, 1, 2, 2, r, g, bl, a)
+			continue
+		}
+
+		frame := e.Frames[int(math.Min(float64(len(e.Frames)-1), t*float64(len(e.Frames))))]
+		if frame == nil {
+			continue
+		}
+		bounds := frame.Bounds()
+		state.texture = frame
+		b.appendQuad(state, &geoM, 0, 0, size, size,
+			float64(bounds.Min.X), float64(bounds.Min.Y), float64(bounds.Max.X), float64(bounds.Max.Y),
+			r, g, bl, a)
+	}
+}
+
+func lerp(a, b, t float64) float64 {
+	return a + (b-a)*t
+}
+
+func (w *World) AddParticleEmitter(emitter *ParticleEmitter) *ParticleEmitter {
+	w.emitterMutex.Lock()
+	defer w.emitterMutex.Unlock()
+
+	emitter.world = w
+	w.Emitters = append(w.Emitters, emitter)
+	return emitter
+}
+
+func (w *World) RemoveParticleEmitter(emitter *ParticleEmitter) {
+	w.emitterMutex.Lock()
+	defer w.emitterMutex.Unlock()
+
+	for i, e := range w.Emitters {
+		if e == emitter {
+			w.Emitters = append(w.Emitters[:i], w.Emitters[i+1:]...)
+			emitter.world = nil
+			break
+		}
+	}
+}
+
+func (w *World) EmitParticles(x, y float64, count int, props *ParticleEmitterProps) *ParticleEmitter {
+	var burst ParticleEmitterProps
+	if props != nil {
+		burst = *props
+	}
+	burst.X = x
+	burst.Y = y
+	burst.AutoRemove = true
+	if burst.MaxParticles < count {
+		burst.MaxParticles = count
+	}
+
+	emitter := NewParticleEmitter(&burst)
+	emitter.Burst(count)
+	return w.AddParticleEmitter(emitter)
+}
+
+func (w *World) clearEmitters() {
+	w.emitterMutex.Lock()
+	defer w.emitterMutex.Unlock()
+
+	for _, emitter := range w.Emitters {
+		emitter.world = nil
+	}
+	w.Emitters = nil
+}
+
+func (w *World) updateEmitters(delta float64) {
+	w.emitterMutex.Lock()
+	defer w.emitterMutex.Unlock()
+
+	alive := w.Emitters[:0]
+	for _, emitter := range w.Emitters {
+		emitter.Update(delta)
+		if emitter.AutoRemove && emitter.Finished() {
+			emitter.world = nil
+			continue
+		}
+		alive = append(alive, emitter)
+	}
+	for i := len(alive); i < len(w.Emitters); i++ {
+		w.Emitters[i] = nil
+	}
+	w.Emitters = alive
+}
+
+func (w *World) prepareEmitters() []*ParticleEmitter {
+	w.emitterMutex.RLock()
+	w.emitterDrawList = append(w.emitterDrawList[:0], w.Emitters...)
+	w.emitterMutex.RUnlock()
+
+	w.layerMutex.RLock()
+	visible := w.emitterDrawList[:0]
+	for _, emitter := range w.emitterDrawList {
+		layer := w.findLayer(emitter.Layer)
+		if layer != nil && !layer.Visible {
+			continue
+		}
+		emitter.layerOrder = w.layerOrder(emitter.Layer)
+		visible = append(visible, emitter)
+	}
+	w.layerMutex.RUnlock()
+
+	slices.SortStableFunc(visible, compareEmitters)
+	return visible
+}
+
+func (w *World) drawEmitter(b *Batch, emitter *ParticleEmitter, lit bool) bool {
+	camera := w.Camera
+	if w.isScreenSpace(emitter.Layer) {
+		if !lit {
+			lit = w.flushLighting(b.target)
+		}
+		camera = Vector2{}
+	}
+	emitter.Draw(b, camera)
+	return lit
+}
+
+func emitterBefore(e *ParticleEmitter, s *Shape) bool {
+	if e.layerOrder != s.layerOrder {
+		return e.layerOrder < s.layerOrder
+	}
+	return e.ZIndex < s.ZIndex
+}
+
+func compareEmitters(a, b *ParticleEmitter) int {
+	if a.layerOrder != b.layerOrder {
+		return cmp.Compare(a.layerOrder, b.layerOrder)
+	}
+	return cmp.Compare(a.ZIndex, b.ZIndex)
+}
@@ -0,0 +1,280 @@
+package life
+
+import (
+	"image/color"
+	"math"
+	"sort"
+
+	"github.com/hajimehoshi/ebiten/v2"
+)
+
+type GradientType string
+
+const (
+	GradientLinear GradientType = "linear"
+	GradientRadial GradientType = "radial"
+
+	maxGradientTextureSize = 512
+)
+
+type ColorStop struct {
+	Offset float64
+	Color  color.Color
+}
+
+type Gradient struct {
+	Type   GradientType
+	Stops  []ColorStop
+	Angle  float64
+	Center Vector2
+	Radius float64
+}
+
+type TilePattern struct {
+	Offset Vector2
+	Scale  float64
+}
+
+type NineSlice struct {
+	Left, Top, Right, Bottom float64
+}
+
+func NewLinearGradient(angle float64, stops ...ColorStop) *Gradient {
+	return &Gradient{
+		Type:  GradientLinear,
+		Angle: angle,
+		Stops: stops,
+	}
+}
+
+func NewRadialGradient(stops ...ColorStop) *Gradient {
+	return &Gradient{
+		Type:   GradientRadial,
+		Stops:  stops,
+		Radius: 1,
+	}
+}
+
+func (g *Gradient) AddStop(offset float64, c color.Color) *Gradient {
+	g.Stops = append(g.Stops, ColorStop{Offset: offset, Color: c})
+	return g
+}
+
+func (g *Gradient) equal(other *Gradient) bool {
+	if g.Type != other.Type || g.Angle != other.Angle || g.Center != other.Center ||
+		g.Radius != other.Radius || len(g.Stops) != len(other.Stops) {
+		return false
+	}
+	for i := range g.Stops {
+		if g.Stops[i] != other.Stops[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func (g *Gradient) colorAt(t float64, sorted []ColorStop) (r, gr, b, a float64) {
+	if len(sorted) == 0 {
+		return 0, 0, 0, 0
+	}
+
+	t = math.Max(0, math.Min(1, t))
+	if t <= sorted[0].Offset {
+		return straightRGBA(sorted[0].Color)
+	}
+	for i := 1; i < len(sorted); i++ {
+		if t <= sorted[i].Offset {
+			from, to := sorted[i-1], sorted[i]
+			span := to.Offset - from.Offset
+			k := 0.0
+			if span > 0 {
+				k = (t - from.Offset) / span
+			}
+			r0, g0, b0, a0 := straightRGBA(from.Color)
+			r1, g1, b1, a1 := straightRGBA(to.Color)
+			return r0 + (r1-r0)*k, g0 + (g1-g0)*k, b0 + (b1-b0)*k, a0 + (a1-a0)*k
+		}
+	}
+	return straightRGBA(sorted[len(sorted)-1].Color)
+}
+
+func straightRGBA(c color.Color) (r, g, b, a float64) {
+	if c == nil {
+		return 0, 0, 0, 0
+	}
+	n := color.NRGBAModel.Convert(c).(color.NRGBA)
+	return float64(n.R) / 255, float64(n.G) / 255, float64(n.B) / 255, float64(n.A) / 255
+}
+
+func (g *Gradient) sortedStops() []ColorStop {
+	sorted := make([]ColorStop, len(g.Stops))
+	copy(sorted, g.Stops)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].Offset < sorted[j].Offset
+	})
+	return sorted
+}
+
+func (g *Gradient) position(x, y, width, height float64) float64 {
+	switch g.Type {
+	case GradientRadial:
+		radius := g.Radius
+		if radius <= 0 {
+			radius = 1
+		}
+		radius *= math.Hypot(width, height) / 2
+		return math.Hypot(x-width*(0.5+g.Center.X), y-height*(0.5+g.Center.Y)) / radius
+	default:
+		dirX, dirY := math.Cos(g.Angle*Deg), math.Sin(g.Angle*Deg)
+		length := math.Abs(width*dirX) + math.Abs(height*dirY)
+		if length == 0 {
+			length = 1
+		}
+		return ((x-width/2)*dirX+(y-height/2)*dirY)/length + 0.5
+	}
+}
+
+func (g *Gradient) render(width, height float64) *ebiten.Image {
+	scale := math.Min(1, maxGradientTextureSize/math.Max(width, height))
+	texW := int(math.Max(1, math.Ceil(width*scale)))
+	texH := int(math.Max(1, math.Ceil(height*scale)))
+
+	sorted := g.sortedStops()
+
+	pixels := make([]byte, texW*texH*4)
+	for y := 0; y < texH; y++ {
+		for x := 0; x < texW; x++ {
+			t := g.position(float64(x)+0.5, float64(y)+0.5, float64(texW), float64(texH))
+
+			r, gr, b, a := g.colorAt(t, sorted)
+			i := (y*texW + x) * 4
+			pixels[i] = byte(r * a * 255)
+			pixels[i+1] = byte(gr * a * 255)
+			pixels[i+2] = byte(b * a * 255)
+			pixels[i+3] = byte(a * 255)
+		}
+	}
+
+	img := ebiten.NewImage(texW, texH)
+	img.WritePixels(pixels)
+	return img
+}
+
+type gradientCache struct {
+	gradient Gradient
+	width    float64
+	height   float64
+	image    *ebiten.Image
+}
+
+func (c *gradientCache) get(g *Gradient, width, height float64) *ebiten.Image {
+	if c.image != nil && c.width == width && c.height == height && c.gradient.equal(g) {
+		return c.image
+	}
+
+	if c.image != nil {
+		c.image.Deallocate()
+	}
+
+	c.gradient = *g
+	c.gradient.Stops = append([]ColorStop(nil), g.Stops...)
+	c.width = width
+	c.height = height
+	c.image = g.render(width, height)
+	return c.image
+}
+
+func (s *Shape) drawPattern(b *Batch, geoM *ebiten.GeoM, m *shapeMesh, width, height float64) {
+	switch s.Pattern {
+	case PatternColor:
+		b.DrawMesh(m.fill.vertices, m.fill.indices, geoM, s.Background, s.Opacity, true)
+
+	case PatternImage:
+		s.drawImage(b)
+
+	case PatternGradient:
+		if s.Gradient == nil {
+			return
+		}
+		if s.gradientCache == nil {
+			s.gradientCache = &gradientCache{}
+		}
+		texture := s.gradientCache.get(s.Gradient, width, height)
+		bounds := texture.Bounds()
+
+		var srcGeoM ebiten.GeoM
+		srcGeoM.Scale(float64(bounds.Dx())/width, float64(bounds.Dy())/height)
+		srcGeoM.Translate(float64(bounds.Min.X), float64(bounds.Min.Y))
+		b.DrawTexturedMesh(m.fill.vertices, m.fill.indices, geoM, texture, &srcGeoM, s.Opacity, true, ebiten.AddressClampToZero)
+
+	case PatternTile:
+		if s.Image == nil {
+			return
+		}
+		tile := s.Tile
+		if tile == nil {
+			tile = &TilePattern{}
+		}
+		scale := tile.Scale
+		if scale <= 0 {
+			scale = 1
+		}
+		bounds := s.Image.Bounds()
+
+		var srcGeoM ebiten.GeoM
+		srcGeoM.Translate(-tile.Offset.X, -tile.Offset.Y)
+		srcGeoM.Scale(1/scale, 1/scale)
+		srcGeoM.Translate(float64(bounds.Min.X), float64(bounds.Min.Y))
+		b.DrawTexturedMesh(m.fill.vertices, m.fill.indices, geoM, s.Image, &srcGeoM, s.Opacity, true, ebiten.AddressRepeat)
+
+	case PatternNineSlice:
+		s.drawNineSlice(b, geoM, width, height)
+	}
+}
+
+func (s *Shape) drawNineSlice(b *Batch, geoM *ebiten.GeoM, width, height float64) {
+	if s.Image == nil {
+		return
+	}
+
+	slice := s.NineSlice
+	if slice == nil {
+		slice = &NineSlice{}
+	}
+
+	bounds := s.Image.Bounds()
+	srcX := [4]float64{
+		float64(bounds.Min.X),
+		float64(bounds.Min.X) + slice.Left,
+		float64(bounds.Max.X) - slice.Right,
+		float64(bounds.Max.X),
+	}
+	srcY := [4]float64{
+		float64(bounds.Min.Y),
+		float64(bounds.Min.Y) + slice.Top,
+		float64(bounds.Max.Y) - slice.Bottom,
+		float64(bounds.Max.Y),
+	}
+
+	left, right := fitInsets(slice.Left, slice.Right, width)
+	top, bottom := fitInsets(slice.Top, slice.Bottom, height)
+	dstX := [4]float64{0, left, width - right, width}
+	dstY := [4]float64{0, top, height - bottom, height}
+
+	for row := 0; row < 3; row++ {
+		for col := 0; col < 3; col++ {
+			b.DrawImageRegion(s.Image, geoM,
+				dstX[col], dstY[row], dstX[col+1], dstY[row+1],
+				srcX[col], srcY[row], srcX[col+1], srcY[row+1],
+				s.Opacity)
+		}
+	}
+}
+
+func fitInsets(a, b, size float64) (float64, float64) {
+	if a+b <= size || a+b == 0 {
+		return a, b
+	}
+	k := size / (a + b)
+	return a * k, b * k
+}
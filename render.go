@@ -0,0 +1,215 @@
+package life
+
+import (
+	"image"
+	"image/color"
+	"sync/atomic"
+
+	"github.com/hajimehoshi/ebiten/v2"
+)
+
+var (
+	whiteImage    = ebiten.NewImage(3, 3)
+	whiteSubImage = whiteImage.SubImage(image.Rect(1, 1, 2, 2)).(*ebiten.Image)
+
+	sharedBatch = &Batch{}
+
+	drawCallCount atomic.Int64
+)
+
+func init() {
+	whiteImage.Fill(color.White)
+}
+
+func countDrawCall() {
+	drawCallCount.Add(1)
+}
+
+type batchState struct {
+	texture   *ebiten.Image
+	antiAlias bool
+	address   ebiten.Address
+	blend     ebiten.Blend
+	filter    ebiten.Filter
+}
+
+type Batch struct {
+	target   *ebiten.Image
+	state    batchState
+	vertices []ebiten.Vertex
+	indices  []uint16
+	options  ebiten.DrawTrianglesOptions
+
+	Filter    ebiten.Filter
+	DrawCalls int
+}
+
+func (b *Batch) Begin(target *ebiten.Image) {
+	b.target = target
+	b.state = batchState{}
+	b.vertices = b.vertices[:0]
+	b.indices = b.indices[:0]
+	b.DrawCalls = 0
+}
+
+func (b *Batch) Flush() {
+	if b.target == nil || len(b.indices) == 0 {
+		b.vertices = b.vertices[:0]
+		b.indices = b.indices[:0]
+		return
+	}
+
+	texture := b.state.texture
+	if texture == nil {
+		texture = whiteSubImage
+	}
+
+	b.options = ebiten.DrawTrianglesOptions{
+		ColorScaleMode: ebiten.ColorScaleModePremultipliedAlpha,
+		Filter:         b.state.filter,
+		AntiAlias:      b.state.antiAlias,
+		Address:        b.state.address,
+		Blend:          b.state.blend,
+	}
+
+	b.target.DrawTriangles(b.vertices, b.indices, texture, &b.options)
+	b.DrawCalls++
+	countDrawCall()
+
+	b.vertices = b.vertices[:0]
+	b.indices = b.indices[:0]
+}
+
+func (b *Batch) End() {
+	b.Flush()
+	b.target = nil
+	b.state = batchState{}
+}
+
+func (b *Batch) reserve(state batchState, vertexCount, indexCount int) uint16 {
+	if state.texture == whiteSubImage {
+		state.texture = nil
+	}
+	if state.texture == nil {
+		state.filter = ebiten.FilterNearest
+	} else if state.address == ebiten.AddressUnsafe {
+		if parent := textureParent(state.texture); parent != nil {
+			state.texture = parent
+		}
+	}
+	if b.state != state ||
+		len(b.vertices)+vertexCount > ebiten.MaxVertexCount ||
+		len(b.indices)+indexCount > ebiten.MaxIndicesCount {
+		b.Flush()
+		b.state = state
+	}
+	return uint16(len(b.vertices))
+}
+
+func premultiply(c color.Color, opacity float64) (r, g, bl, a float32) {
+	if c == nil {
+		return 0, 0, 0, 0
+	}
+	cr, cg, cb, ca := c.RGBA()
+	o := float32(opacity)
+	return float32(cr) / 0xffff * o, float32(cg) / 0xffff * o, float32(cb) / 0xffff * o, float32(ca) / 0xffff * o
+}
+
+func (b *Batch) appendVertex(geoM *ebiten.GeoM, x, y, srcX, srcY float64, r, g, bl, a float32) {
+	dx, dy := geoM.Apply(x, y)
+	b.vertices = append(b.vertices, ebiten.Vertex{
+		DstX:   float32(dx),
+		DstY:   float32(dy),
+		SrcX:   float32(srcX),
+		SrcY:   float32(srcY),
+		ColorR: r,
+		ColorG: g,
+		ColorB: bl,
+		ColorA: a,
+	})
+}
+
+func (b *Batch) FillRect(geoM *ebiten.GeoM, width, height float64, c color.Color, opacity float64) {
+	r, g, bl, a := premultiply(c, opacity)
+	if a <= 0 {
+		return
+	}
+
+	b.appendQuad(batchState{}, geoM, 0, 0, width, height, 1, 1, 2, 2, r, g, bl, a)
+}
+
+func (b *Batch) DrawMesh(vertices []ebiten.Vertex, indices []uint16, geoM *ebiten.GeoM, c color.Color, opacity float64, antiAlias bool) {
+	r, g, bl, a := premultiply(c, opacity)
+	if a <= 0 || len(indices) == 0 {
+		return
+	}
+
+	base := b.reserve(batchState{antiAlias: antiAlias}, len(vertices), len(indices))
+	for _, v := range vertices {
+		b.appendVertex(geoM, float64(v.DstX), float64(v.DstY), 1.5, 1.5, r, g, bl, a)
+	}
+	for _, i := range indices {
+		b.indices = append(b.indices, base+i)
+	}
+}
+
+func (b *Batch) DrawTexturedMesh(vertices []ebiten.Vertex, indices []uint16, geoM *ebiten.GeoM, texture *ebiten.Image, srcGeoM *ebiten.GeoM, opacity float64, antiAlias bool, address ebiten.Address) {
+	if texture == nil || opacity <= 0 || len(indices) == 0 {
+		return
+	}
+
+	o := float32(opacity)
+	base := b.reserve(batchState{texture: texture, antiAlias: antiAlias, address: address, filter: b.Filter}, len(vertices), len(indices))
+	for _, v := range vertices {
+		x, y := float64(v.DstX), float64(v.DstY)
+		srcX, srcY := srcGeoM.Apply(x, y)
+		b.appendVertex(geoM, x, y, srcX, srcY, o, o, o, o)
+	}
+	for _, i := range indices {
+		b.indices = append(b.indices, base+i)
+	}
+}
+
+func (b *Batch) DrawImage(img *ebiten.Image, geoM *ebiten.GeoM, opacity float64) {
+	if img == nil {
+		return
+	}
+
+	bounds := img.Bounds()
+	minX, minY := float64(bounds.Min.X), float64(bounds.Min.Y)
+	maxX, maxY := float64(bounds.Max.X), float64(bounds.Max.Y)
+	b.DrawImageRegion(img, geoM, 0, 0, maxX-minX, maxY-minY, minX, minY, maxX, maxY, opacity)
+}
+
+func (b *Batch) DrawImageRegion(img *ebiten.Image, geoM *ebiten.GeoM, dstX0, dstY0, dstX1, dstY1, srcX0, srcY0, srcX1, srcY1, opacity float64) {
+	if img == nil || opacity <= 0 {
+		return
+	}
+
+	o := float32(opacity)
+	b.appendQuad(batchState{texture: img, filter: b.Filter}, geoM, dstX0, dstY0, dstX1, dstY1, srcX0, srcY0, srcX1, srcY1, o, o, o, o)
+}
+
+func (b *Batch) appendQuad(state batchState, geoM *ebiten.GeoM, dstX0, dstY0, dstX1, dstY1, srcX0, srcY0, srcX1, srcY1 float64, r, g, bl, a float32) {
+	if dstX0 == dstX1 || dstY0 == dstY1 {
+		return
+	}
+
+	base := b.reserve(state, 4, 6)
+	b.appendVertex(geoM, dstX0, dstY0, srcX0, srcY0, r, g, bl, a)
+	b.appendVertex(geoM, dstX1, dstY0, srcX1, srcY0, r, g, bl, a)
+	b.appendVertex(geoM, dstX0, dstY1, srcX0, srcY1, r, g, bl, a)
+	b.appendVertex(geoM, dstX1, dstY1, srcX1, srcY1, r, g, bl, a)
+	b.indices = append(b.indices, base, base+1, base+2, base+1, base+3, base+2)
+}
+
+func circleSegments(radius float64) int {
+	segments := int(radius)
+	if segments < 12 {
+		segments = 12
+	}
+	if segments > 96 {
+		segments = 96
+	}
+	return segments
+}
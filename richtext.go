@@ -0,0 +1,711 @@
+package life
+
+import (
+	"image/color"
+	"math"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/text"
+	"golang.org/x/image/colornames"
+	"golang.org/x/image/font"
+)
+
+const (
+	italicSkew       = -0.2
+	defaultWaveSize  = 3.0
+	defaultShakeSize = 1.5
+
+	iconPlaceholder = '\uFFFC'
+)
+
+type richStyle struct {
+	color  color.Color
+	tinted bool
+	bold   bool
+	italic bool
+	size   float64
+	wave   float64
+	shake  float64
+}
+
+type richRun struct {
+	text  string
+	icon  *ebiten.Image
+	style richStyle
+}
+
+type richTag struct {
+	name  string
+	style richStyle
+}
+
+type richItem struct {
+	text       string
+	icon       *ebiten.Image
+	style      richStyle
+	face       font.Face
+	scale      float64
+	fakeBold   bool
+	fakeItalic bool
+	space      bool
+	newline    bool
+	x          float64
+	width      float64
+	ascent     float64
+	descent    float64
+}
+
+type richLine struct {
+	items   []richItem
+	x, y    float64
+	width   float64
+	ascent  float64
+	descent float64
+}
+
+type richLayout struct {
+	lines  []richLine
+	x, y   float64
+	width  float64
+	height float64
+}
+
+func StripMarkup(markup string) string {
+	var sb strings.Builder
+	for _, run := range parseMarkup(markup, richStyle{}) {
+		if run.icon != nil {
+			sb.WriteRune(iconPlaceholder)
+			continue
+		}
+		sb.WriteString(run.text)
+	}
+	return sb.String()
+}
+
+func parseMarkup(markup string, base richStyle) []richRun {
+	var runs []richRun
+	var sb strings.Builder
+	stack := []richTag{{style: base}}
+
+	flush := func() {
+		if sb.Len() > 0 {
+			runs = append(runs, richRun{text: sb.String(), style: stack[len(stack)-1].style})
+			sb.Reset()
+		}
+	}
+
+	for i := 0; i < len(markup); {
+		if markup[i] == '[' {
+			if strings.HasPrefix(markup[i:], "[[") {
+				sb.WriteByte('[')
+				i += 2
+				continue
+			}
+
+			if end := strings.IndexByte(markup[i:], ']'); end > 0 {
+				tag := markup[i+1 : i+end]
+				style := stack[len(stack)-1].style
+				name, value, _ := strings.Cut(tag, "=")
+				name = strings.ToLower(strings.TrimSpace(name))
+				value = strings.TrimSpace(value)
+
+				if closing, ok := strings.CutPrefix(name, "/"); ok {
+					if j := openTag(stack, closing); j > 0 {
+						flush()
+						stack = stack[:j]
+						i += end + 1
+						continue
+					}
+				} else if name == "icon" {
+					if icon := GetSprite(value); icon != nil {
+						flush()
+						runs = append(runs, richRun{icon: icon, style: style})
+						i += end + 1
+						continue
+					}
+				} else if applyRichTag(&style, name, value) {
+					flush()
+					stack = append(stack, richTag{name: name, style: style})
+					i += end + 1
+					continue
+				}
+			}
+		}
+
+		sb.WriteByte(markup[i])
+		i++
+	}
+
+	flush()
+	return runs
+}
+
+func openTag(stack []richTag, name string) int {
+	for j := len(stack) - 1; j > 0; j-- {
+		if stack[j].name == name {
+			return j
+		}
+	}
+	return -1
+}
+
+func applyRichTag(style *richStyle, name, value string) bool {
+	switch name {
+	case "color":
+		c, ok := parseColor(value)
+		if !ok {
+			return false
+		}
+		style.color = c
+		style.tinted = true
+	case "b":
+		style.bold = true
+	case "i":
+		style.italic = true
+	case "size":
+		size, err := strconv.ParseFloat(value, 64)
+		if err != nil || size <= 0 {
+			return false
+		}
+		style.size = size
+	case "wave":
+		style.wave = parseAmount(value, defaultWaveSize)
+	case "shake":
+		style.shake = parseAmount(value, defaultShakeSize)
+	default:
+		return false
+	}
+	return true
+}
+
+func parseAmount(value string, fallback float64) float64 {
+	if amount, err := strconv.ParseFloat(value, 64); err == nil && amount > 0 {
+		return amount
+	}
+	return fallback
+}
+
+func parseColor(value string) (color.Color, bool) {
+	if c, ok := colornames.Map[strings.ToLower(value)]; ok {
+		return c, true
+	}
+
+	hex, ok := strings.CutPrefix(value, "#")
+	if !ok {
+		return nil, false
+	}
+	if len(hex) == 3 || len(hex) == 4 {
+		expanded := make([]byte, 0, len(hex)*2)
+		for i := 0; i < len(hex); i++ {
+			expanded = append(expanded, hex[i], hex[i])
+		}
+		hex = string(expanded)
+	}
+	if len(hex) == 6 {
+		hex += "ff"
+	}
+	if len(hex) != 8 {
+		return nil, false
+	}
+
+	n, err := strconv.ParseUint(hex, 16, 32)
+	if err != nil {
+		return nil, false
+	}
+	return color.NRGBA{uint8(n >> 24), uint8(n >> 16), uint8(n >> 8), uint8(n)}, true
+}
+
+func (props *TextProps) styledFace(style richStyle) (face font.Face, scale float64, fakeBold, fakeItalic bool) {
+	size := props.Size
+	if style.size > 0 {
+		size = style.size
+	}
+
+	if props.Font == nil && props.Type != "" {
+		suffix := ""
+		switch {
+		case style.bold && style.italic:
+			suffix = "-bold-italic"
+		case style.bold:
+			suffix = "-bold"
+		case style.italic:
+			suffix = "-italic"
+		}
+		if suffix != "" {
+			if face, err := GetFont(props.Type+suffix, size); err == nil {
+				return face, 1, false, false
+			}
+		}
+		if face, err := GetFont(props.Type, size); err == nil {
+			return face, 1, style.bold, style.italic
+		}
+	}
+
+	face = props.face()
+	fakeBold, fakeItalic = style.bold, style.italic
+	switch {
+	case style.bold && style.italic && props.BoldItalicFont != nil:
+		face, fakeBold, fakeItalic = props.BoldItalicFont, false, false
+	case style.bold && props.BoldFont != nil:
+		face, fakeBold = props.BoldFont, false
+	case style.italic && props.ItalicFont != nil:
+		face, fakeItalic = props.ItalicFont, false
+	}
+
+	scale = 1
+	if style.size > 0 {
+		base := props.Size
+		if base <= 0 {
+			base = fixedToFloat(props.face().Metrics().Height)
+		}
+		if base > 0 {
+			scale = style.size / base
+		}
+	}
+	return face, scale, fakeBold, fakeItalic
+}
+
+func (props *TextProps) richItems() []richItem {
+	var items []richItem
+	for _, run := range parseMarkup(props.Text, richStyle{color: props.Color}) {
+		face, scale, fakeBold, fakeItalic := props.styledFace(run.style)
+		item := richItem{
+			style:      run.style,
+			face:       face,
+			scale:      scale,
+			fakeBold:   fakeBold,
+			fakeItalic: fakeItalic,
+		}
+
+		metrics := face.Metrics()
+		item.ascent = fixedToFloat(metrics.Ascent) * scale
+		item.descent = fixedToFloat(metrics.Descent) * scale
+
+		if run.text == "" {
+			if run.icon == nil {
+				continue
+			}
+			bounds := run.icon.Bounds()
+			item.icon = run.icon
+			if bounds.Dy() > 0 {
+				item.width = (item.ascent + item.descent) * float64(bounds.Dx()) / float64(bounds.Dy())
+			}
+			items = append(items, item)
+			continue
+		}
+
+		value := strings.ReplaceAll(run.text, "\r\n", "\n")
+		for value != "" {
+			end := strings.IndexFunc(value, unicode.IsSpace)
+			if end == 0 {
+				r, size := utf8.DecodeRuneInString(value)
+				word := item
+				word.text = value[:size]
+				word.newline = r == '\n'
+				word.space = !word.newline
+				if word.space {
+					word.width = word.measure(word.text)
+				}
+				items = append(items, word)
+				value = value[size:]
+				continue
+			}
+			if end < 0 {
+				end = len(value)
+			}
+			word := item
+			word.text = value[:end]
+			word.width = word.measure(word.text)
+			items = append(items, word)
+			value = value[end:]
+		}
+	}
+	return items
+}
+
+func (item *richItem) measure(value string) float64 {
+	width := measureString(item.face, value) * item.scale
+	if item.fakeBold && !item.space {
+		width++
+	}
+	return width
+}
+
+func (item richItem) cut(split int) (richItem, richItem) {
+	head, tail := item, item
+	head.text, tail.text = item.text[:split], item.text[split:]
+	head.width, tail.width = item.measure(head.text), item.measure(tail.text)
+	return head, tail
+}
+
+func layoutRichText(screen *ebiten.Image, props *TextProps) richLayout {
+	baseMetrics := props.face().Metrics()
+	baseAscent := fixedToFloat(baseMetrics.Ascent)
+	baseDescent := fixedToFloat(baseMetrics.Descent)
+	gap := math.Max(0, fixedToFloat(baseMetrics.Height)-baseAscent-baseDescent)
+
+	spacing := props.LineSpacing
+	if spacing == 0 {
+		spacing = 1
+	}
+
+	var layout richLayout
+	line := richLine{}
+	var pending, word []richItem
+	wordWidth := 0.0
+
+	endLine := func() {
+		line.ascent, line.descent = baseAscent, baseDescent
+		for _, item := range line.items {
+			line.ascent = math.Max(line.ascent, item.ascent)
+			line.descent = math.Max(line.descent, item.descent)
+		}
+		layout.lines = append(layout.lines, line)
+		line = richLine{}
+		pending = pending[:0]
+	}
+
+	place := func(item richItem) {
+		item.x = line.width
+		line.width += item.width
+		line.items = append(line.items, item)
+	}
+
+	placeWord := func() {
+		if len(word) == 0 {
+			return
+		}
+		spaceWidth := 0.0
+		for _, item := range pending {
+			spaceWidth += item.width
+		}
+		if props.MaxWidth > 0 && len(line.items) > 0 && line.width+spaceWidth+wordWidth > props.MaxWidth {
+			endLine()
+		}
+		for _, item := range pending {
+			place(item)
+		}
+
+		// Words wider than a whole line are broken like LayoutText does.
+		oversized := props.MaxWidth > 0 && wordWidth > props.MaxWidth
+		for _, item := range word {
+			for oversized && item.icon == nil && line.width+item.width > props.MaxWidth && utf8.RuneCountInString(item.text) > 1 {
+				room := props.MaxWidth - line.width
+				head, tail := item.cut(breakWord(item.face, item.text, room/item.scale))
+				if len(line.items) > 0 && head.width > room {
+					endLine()
+					continue
+				}
+				place(head)
+				endLine()
+				item = tail
+			}
+			if oversized && len(line.items) > 0 && line.width+item.width > props.MaxWidth {
+				endLine()
+			}
+			place(item)
+		}
+		pending = pending[:0]
+		word = word[:0]
+		wordWidth = 0
+	}
+
+	for _, item := range props.richItems() {
+		switch {
+		case item.newline:
+			placeWord()
+			endLine()
+		case item.space:
+			placeWord()
+			if len(line.items) > 0 {
+				pending = append(pending, item)
+			}
+		default:
+			word = append(word, item)
+			wordWidth += item.width
+		}
+	}
+	placeWord()
+	endLine()
+
+	for i := range layout.lines {
+		current := &layout.lines[i]
+		layout.width = math.Max(layout.width, current.width)
+		if i == 0 {
+			current.y = current.ascent
+			continue
+		}
+		previous := &layout.lines[i-1]
+		current.y = previous.y + (previous.descent+current.ascent+gap)*spacing
+	}
+	last := layout.lines[len(layout.lines)-1]
+	layout.height = last.y + last.descent
+
+	anchorX := props.X
+	align := props.Align
+	if props.FromEnd && screen != nil {
+		anchorX = float64(screen.Bounds().Dx()) - props.X
+		if align == "" {
+			align = AlignRight
+		}
+	}
+
+	top := props.Y - layout.lines[0].ascent
+	switch props.VerticalAlign {
+	case AlignTop:
+		top = props.Y
+	case AlignMiddle:
+		top = props.Y - layout.height/2
+	case AlignBottom:
+		top = props.Y - layout.height
+	}
+
+	layout.x = alignX(align, anchorX, layout.width)
+	layout.y = top
+	for i := range layout.lines {
+		current := &layout.lines[i]
+		current.x = alignX(align, anchorX, current.width)
+		current.y += top
+	}
+
+	return layout
+}
+
+func alignX(align TextAlign, anchor, width float64) float64 {
+	switch align {
+	case AlignCenter:
+		return anchor - width/2
+	case AlignRight:
+		return anchor - width
+	}
+	return anchor
+}
+
+func (l *richLayout) textLayout() TextLayout {
+	layout := TextLayout{
+		Lines:  make([]TextLine, len(l.lines)),
+		X:      l.x,
+		Y:      l.y,
+		Width:  l.width,
+		Height: l.height,
+	}
+	for i, line := range l.lines {
+		var sb strings.Builder
+		for _, item := range line.items {
+			sb.WriteString(item.text)
+		}
+		layout.Lines[i] = TextLine{Text: sb.String(), X: line.x, Y: line.y, Width: line.width}
+	}
+	if len(l.lines) > 0 {
+		layout.Ascent = l.lines[0].ascent
+		layout.Descent = l.lines[len(l.lines)-1].descent
+	}
+	if len(l.lines) > 1 {
+		layout.LineHeight = (l.lines[len(l.lines)-1].y - l.lines[0].y) / float64(len(l.lines)-1)
+	} else {
+		layout.LineHeight = layout.Ascent + layout.Descent
+	}
+	return layout
+}
+
+type richDrawer struct {
+	target    *ebiten.Image
+	layout    *richLayout
+	transform *ebiten.GeoM
+	opacity   float64
+	now       float64
+	visible   int
+	options   ebiten.DrawImageOptions
+}
+
+func drawRichLayout(target *ebiten.Image, props *TextProps, layout *richLayout, transform *ebiten.GeoM, opacity float64) {
+	d := &richDrawer{
+		target:    target,
+		layout:    layout,
+		transform: transform,
+		opacity:   opacity,
+		now:       props.Time.Seconds(),
+		visible:   -1,
+	}
+	if props.Typewriter != nil {
+		d.visible = props.Typewriter.Visible()
+		if d.visible == 0 {
+			return
+		}
+	}
+
+	if shadow := props.Shadow; shadow != nil {
+		offset := shadow.Offset
+		if offset == (Vector2{}) {
+			offset = Vector2{X: 2, Y: 2}
+		}
+		shadowColor := shadow.Color
+		if shadowColor == nil {
+			shadowColor = color.RGBA{0, 0, 0, 160}
+		}
+		d.draw(offset.X, offset.Y, shadowColor, nil)
+	}
+
+	if outline := props.Outline; outline != nil && outline.Width > 0 {
+		outlineColor := outline.Color
+		if outlineColor == nil {
+			outlineColor = color.RGBA{0, 0, 0, 255}
+		}
+		steps := int(math.Min(32, math.Max(8, math.Ceil(2*math.Pi*outline.Width))))
+		for i := 0; i < steps; i++ {
+			angle := float64(i) / float64(steps) * 2 * math.Pi
+			d.draw(math.Cos(angle)*outline.Width, math.Sin(angle)*outline.Width, outlineColor, nil)
+		}
+	}
+
+	// GlyphColor and Gradient color every glyph that has no [color] tag.
+	glyphColor := props.GlyphColor
+	if glyphColor == nil && props.Gradient != nil && len(props.Gradient.Stops) > 0 {
+		glyphColor = gradientGlyphColor(props.Gradient, &TextLayout{X: layout.x, Y: layout.y, Width: layout.width, Height: layout.height})
+	}
+	d.draw(0, 0, nil, glyphColor)
+}
+
+// draw renders one pass of the layout. A non-nil fill replaces every glyph
+// color and skips icons, which is how shadows and outlines are drawn.
+func (d *richDrawer) draw(dx, dy float64, fill color.Color, glyphColor func(index int, r rune, x, y float64) color.Color) {
+	budget := d.visible
+	index := 0
+
+	for _, line := range d.layout.lines {
+		for _, item := range line.items {
+			if item.space || item.newline {
+				continue
+			}
+			if budget == 0 {
+				return
+			}
+
+			x, y := line.x+item.x, line.y
+			if item.icon != nil {
+				if fill == nil {
+					ox, oy := d.offset(item.style, index)
+					d.drawIcon(item, x+ox, y-item.ascent+oy)
+				}
+				index++
+				if budget > 0 {
+					budget--
+				}
+				continue
+			}
+
+			c := fill
+			if c == nil {
+				c = item.style.color
+			}
+			if c == nil {
+				c = color.RGBA{255, 255, 255, 255}
+			}
+			perGlyph := fill == nil && glyphColor != nil && !item.style.tinted
+
+			if !perGlyph && item.style.wave == 0 && item.style.shake == 0 && budget < 0 {
+				d.drawString(item, item.text, x+dx, y+dy, c)
+				index += countGlyphs(item.text)
+				continue
+			}
+
+			previous := rune(-1)
+			for i, r := range item.text {
+				if previous >= 0 {
+					x += fixedToFloat(item.face.Kern(previous, r)) * item.scale
+				}
+				if budget == 0 {
+					return
+				}
+				advance, _ := item.face.GlyphAdvance(r)
+				width := fixedToFloat(advance) * item.scale
+
+				glyph := c
+				if perGlyph {
+					glyph = glyphColor(index, r, x+width/2, y-item.ascent/2)
+				}
+				ox, oy := d.offset(item.style, index)
+				d.drawString(item, item.text[i:i+utf8.RuneLen(r)], x+dx+ox, y+dy+oy, glyph)
+
+				x += width
+				previous = r
+				index++
+				if budget > 0 {
+					budget--
+				}
+			}
+		}
+	}
+}
+
+func (d *richDrawer) offset(style richStyle, index int) (float64, float64) {
+	var dx, dy float64
+	if style.wave > 0 {
+		dy += math.Sin(d.now*6+float64(index)*0.6) * style.wave
+	}
+	if style.shake > 0 {
+		// Derived from the glyph and the game time rather than drawn at random,
+		// so every pass of a glyph shakes together and a paused world holds still.
+		frame := int(d.now * 60)
+		dx += shakeNoise(index, frame, 0) * style.shake
+		dy += shakeNoise(index, frame, 1) * style.shake
+	}
+	return dx, dy
+}
+
+func shakeNoise(index, frame, axis int) float64 {
+	h := uint32(index)*374761393 + uint32(frame)*668265263 + uint32(axis)*2246822519
+	h = (h ^ h>>13) * 1274126177
+	h ^= h >> 16
+	return float64(h)/math.MaxUint32*2 - 1
+}
+
+func (d *richDrawer) drawString(item richItem, value string, x, y float64, c color.Color) {
+	op := &d.options
+	op.ColorScale.Reset()
+	op.ColorScale.ScaleWithColor(c)
+	op.ColorScale.ScaleAlpha(float32(d.opacity))
+	op.Filter = ebiten.FilterLinear
+
+	passes := 1
+	if item.fakeBold {
+		passes = 2
+	}
+	for pass := 0; pass < passes; pass++ {
+		op.GeoM.Reset()
+		if item.fakeItalic {
+			op.GeoM.Skew(italicSkew, 0)
+		}
+		op.GeoM.Scale(item.scale, item.scale)
+		op.GeoM.Translate(math.Round(x)+float64(pass), math.Round(y))
+		if d.transform != nil {
+			op.GeoM.Concat(*d.transform)
+		}
+		text.DrawWithOptions(d.target, value, item.face, op)
+		countDrawCall()
+	}
+}
+
+func (d *richDrawer) drawIcon(item richItem, x, y float64) {
+	bounds := item.icon.Bounds()
+	if bounds.Dx() == 0 || bounds.Dy() == 0 {
+		return
+	}
+
+	op := &d.options
+	op.GeoM.Reset()
+	op.GeoM.Scale(item.width/float64(bounds.Dx()), (item.ascent+item.descent)/float64(bounds.Dy()))
+	op.GeoM.Translate(math.Round(x), math.Round(y))
+	if d.transform != nil {
+		op.GeoM.Concat(*d.transform)
+	}
+	op.ColorScale.Reset()
+	op.ColorScale.ScaleAlpha(float32(d.opacity))
+	op.Filter = ebiten.FilterLinear
+	d.target.DrawImage(item.icon, op)
+	countDrawCall()
+}
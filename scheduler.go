@@ -0,0 +1,299 @@
+package life
+
+import (
+	"runtime"
+	"time"
+)
+
+type Timer struct {
+	Interval time.Duration
+	Repeat   bool
+
+	remaining float64
+	callback  func()
+	cancelled bool
+}
+
+func (t *Timer) Cancel() {
+	t.cancelled = true
+}
+
+func (t *Timer) Cancelled() bool {
+	return t.cancelled
+}
+
+func (t *Timer) Remaining() time.Duration {
+	return time.Duration(t.remaining * float64(time.Second))
+}
+
+func (t *Timer) update(delta float64) {
+	t.remaining -= delta
+	for !t.cancelled && t.remaining <= 0 {
+		t.callback()
+		if !t.Repeat {
+			t.cancelled = true
+			return
+		}
+
+		interval := t.Interval.Seconds()
+		if interval <= 0 {
+			t.remaining = 0
+			return
+		}
+		t.remaining += interval
+	}
+}
+
+func (w *World) After(delay time.Duration, callback func()) *Timer {
+	return w.addTimer(&Timer{
+		Interval:  delay,
+		remaining: delay.Seconds(),
+		callback:  callback,
+	})
+}
+
+func (w *World) Every(interval time.Duration, callback func()) *Timer {
+	return w.addTimer(&Timer{
+		Interval:  interval,
+		Repeat:    true,
+		remaining: interval.Seconds(),
+		callback:  callback,
+	})
+}
+
+func (w *World) addTimer(t *Timer) *Timer {
+	w.schedulerMutex.Lock()
+	w.timers = append(w.timers, t)
+	w.schedulerMutex.Unlock()
+	return t
+}
+
+type Coroutine struct {
+	world     *World
+	resume    chan bool
+	yield     chan struct{}
+	ready     func(delta float64) bool
+	done      bool
+	cancelled bool
+}
+
+func (w *World) Go(routine func(co *Coroutine)) *Coroutine {
+	co := &Coroutine{
+		world:  w,
+		resume: make(chan bool),
+		yield:  make(chan struct{}),
+	}
+
+	w.schedulerMutex.Lock()
+	w.coroutines = append(w.coroutines, co)
+	w.schedulerMutex.Unlock()
+
+	go func() {
+		defer func() {
+			co.done = true
+			co.yield <- struct{}{}
+		}()
+		if !<-co.resume {
+			runtime.Goexit()
+		}
+		routine(co)
+	}()
+
+	co.step()
+	return co
+}
+
+func (co *Coroutine) step() {
+	previous := co.world.runningCoroutine
+	co.world.runningCoroutine = co
+	co.resume <- true
+	<-co.yield
+	co.world.runningCoroutine = previous
+}
+
+func (co *Coroutine) suspend(ready func(delta float64) bool) {
+	if co.cancelled {
+		runtime.Goexit()
+	}
+
+	co.ready = ready
+	co.yield <- struct{}{}
+	if !<-co.resume {
+		runtime.Goexit()
+	}
+}
+
+func (co *Coroutine) Wait(duration time.Duration) {
+	remaining := duration.Seconds()
+	co.suspend(func(delta float64) bool {
+		remaining -= delta
+		return remaining <= 0
+	})
+}
+
+func (co *Coroutine) WaitFrames(frames int) {
+	co.suspend(func(float64) bool {
+		frames--
+		return frames <= 0
+	})
+}
+
+func (co *Coroutine) Yield() {
+	co.WaitFrames(1)
+}
+
+func (co *Coroutine) WaitUntil(condition func() bool) {
+	if condition() {
+		return
+	}
+	co.suspend(func(float64) bool {
+		return condition()
+	})
+}
+
+func (co *Coroutine) WaitForEvent(event EventType) interface{} {
+	return co.WaitForEventOn(co.world.EventEmitter, event)
+}
+
+func (co *Coroutine) WaitForEventOn(emitter *EventEmitter, event EventType) interface{} {
+	waiter := &eventWaiter{}
+	co.world.addEventWaiter(emitter, event, waiter)
+
+	co.suspend(func(float64) bool {
+		return waiter.received
+	})
+	return waiter.data
+}
+
+func (co *Coroutine) Cancel() {
+	if co.done || co.cancelled {
+		return
+	}
+	co.cancelled = true
+
+	if co.world.runningCoroutine == co {
+		return
+	}
+	co.resume <- false
+	<-co.yield
+}
+
+func (co *Coroutine) Done() bool {
+	return co.done
+}
+
+type eventWaitKey struct {
+	emitter *EventEmitter
+	event   EventType
+}
+
+type eventWaiter struct {
+	received bool
+	data     interface{}
+}
+
+func (w *World) addEventWaiter(emitter *EventEmitter, event EventType, waiter *eventWaiter) {
+	key := eventWaitKey{emitter: emitter, event: event}
+
+	w.schedulerMutex.Lock()
+	defer w.schedulerMutex.Unlock()
+
+	if w.eventWaiters == nil {
+		w.eventWaiters = make(map[eventWaitKey][]*eventWaiter)
+	}
+	if _, ok := w.eventWaiters[key]; !ok {
+		emitter.On(event, func(data interface{}) {
+			w.schedulerMutex.Lock()
+			waiters := w.eventWaiters[key]
+			w.eventWaiters[key] = nil
+			w.schedulerMutex.Unlock()
+
+			for _, waiter := range waiters {
+				waiter.received = true
+				waiter.data = data
+			}
+		})
+	}
+	w.eventWaiters[key] = append(w.eventWaiters[key], waiter)
+}
+
+func (w *World) updateScheduler(delta float64) {
+	w.gameTime += delta
+
+	w.schedulerMutex.Lock()
+	timers := append(w.timerList[:0], w.timers...)
+	w.timerList = timers
+	coroutines := append(w.coroutineList[:0], w.coroutines...)
+	w.coroutineList = coroutines
+	w.schedulerMutex.Unlock()
+
+	for _, t := range timers {
+		if !t.cancelled {
+			t.update(delta)
+		}
+	}
+
+	for _, co := range coroutines {
+		if co.done || co.cancelled {
+			continue
+		}
+		if co.ready == nil || co.ready(delta) {
+			co.ready = nil
+			co.step()
+		}
+	}
+
+	w.schedulerMutex.Lock()
+	activeTimers := w.timers[:0]
+	for _, t := range w.timers {
+		if !t.cancelled {
+			activeTimers = append(activeTimers, t)
+		}
+	}
+	for i := len(activeTimers); i < len(w.timers); i++ {
+		w.timers[i] = nil
+	}
+	w.timers = activeTimers
+
+	activeCoroutines := w.coroutines[:0]
+	for _, co := range w.coroutines {
+		if !co.done {
+			activeCoroutines = append(activeCoroutines, co)
+		}
+	}
+	for i := len(activeCoroutines); i < len(w.coroutines); i++ {
+		w.coroutines[i] = nil
+	}
+	w.coroutines = activeCoroutines
+	w.schedulerMutex.Unlock()
+}
+
+func (w *World) CancelScheduled() {
+	w.schedulerMutex.Lock()
+	timers := w.timers
+	coroutines := w.coroutines
+	w.timers = nil
+	w.coroutines = nil
+	for key := range w.eventWaiters {
+		w.eventWaiters[key] = nil
+	}
+	w.schedulerMutex.Unlock()
+
+	for _, t := range timers {
+		t.Cancel()
+	}
+	for _, co := range coroutines {
+		co.Cancel()
+	}
+}
+
+func (w *World) GameTime() time.Duration {
+	return time.Duration(w.gameTime * float64(time.Second))
+}
+
+func (w *World) SetTimeScale(scale float64) {
+	if scale < 0 {
+		scale = 0
+	}
+	w.TimeScale = scale
+}
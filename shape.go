@@ -6,6 +6,7 @@ import (
 
 	"github.com/ByteArena/box2d"
 	"github.com/hajimehoshi/ebiten/v2"
+	"golang.org/x/image/font"
 )
 
 type Border struct {
@@ -35,17 +36,36 @@ type Shape struct {
 	Mass          float64
 	Density       float64
 	ZIndex        int
+	Layer         RenderLayer
 	Scale         float64
 	Opacity       float64
 
 	Pattern    PatternType
 	Background color.Color
 	Image      *ebiten.Image
+	Gradient   *Gradient
+	Tile       *TilePattern
+	NineSlice  *NineSlice
 	Border     *Border
+	Stroke     *Stroke
 	Flip       struct{ X, Y bool }
+	Nearest    bool
+
+	CornerRadius float64
+	ArcStart     float64
+	ArcEnd       float64
+
+	Text        string
+	Font        font.Face
+	FontSize    float64
+	FontType    string
+	TextAlign   TextAlign
+	LineSpacing float64
+	WrapWidth   float64
 
 	IsBody   bool
 	Physics  bool
+	Occluder bool
 	Velocity Vector2
 	Speed    float64
 	Rebound  float64
@@ -57,16 +77,23 @@ type Shape struct {
 
 	Hovered bool
 	Clicked bool
+	Cursor  CursorType
+
+	Animator   *Animator
+	animations []*Animation
+	tweens     []*Tweener
 
 	LineCoordinates struct{ X1, Y1, X2, Y2 float64 }
 
 	OnCollisionFunc       func(*Shape)
 	OnFinishCollisionFunc func(*Shape)
 
-	world *World
-
-	cachedColorImage *ebiten.Image
-	lastBackground   color.Color
+	world         *World
+	drawOrder     int64
+	layerOrder    int
+	mesh          *shapeMesh
+	gradientCache *gradientCache
+	textLayout    *textLayoutCache
 
 	directions *Axis
 	Ghost      bool
@@ -81,10 +108,14 @@ type ShapeProps struct {
 	Width, Height         float64
 	Radius                float64
 	ZIndex                int
+	Layer                 RenderLayer
 	IsBody                bool
 	Pattern               PatternType
 	Background            color.Color
 	Image                 *ebiten.Image
+	Gradient              *Gradient
+	Tile                  *TilePattern
+	NineSlice             *NineSlice
 	Name                  string
 	Rotation              float64
 	RotationLock          bool
@@ -98,15 +129,29 @@ type ShapeProps struct {
 	Speed                 float64
 	Velocity              Vector2
 	Border                *Border
+	Stroke                *Stroke
+	CornerRadius          float64
+	ArcStart              float64
+	ArcEnd                float64
+	Text                  string
+	Font                  font.Face
+	FontSize              float64
+	FontType              string
+	TextAlign             TextAlign
+	LineSpacing           float64
+	WrapWidth             float64
 	Flip                  struct{ X, Y bool }
+	Nearest               bool
 	Opacity               float64
 	LineCoordinates       struct {
 		A Vector2
 		B Vector2
 	}
 	Ghost                bool
+	Occluder             bool
 	Scale                float64
 	LastCollisionImpulse float64
+	Cursor               CursorType
 }
 
 func NewShape(props *ShapeProps) *Shape {
@@ -123,6 +168,18 @@ func NewShape(props *ShapeProps) *Shape {
 	if props.Tag == "" {
 		props.Tag = "unknown"
 	}
+	if props.Type == ShapeText {
+		width, height := measureShapeText(props)
+		if props.Width == 0 {
+			props.Width = width
+		}
+		if props.Height == 0 {
+			props.Height = height
+		}
+		if props.Background == nil {
+			props.Background = color.RGBA{255, 255, 255, 255}
+		}
+	}
 	if props.Width == 0 {
 		props.Width = 10
 	}
@@ -144,7 +201,10 @@ func NewShape(props *ShapeProps) *Shape {
 	if props.Pattern == "" {
 		props.Pattern = PatternColor
 	}
-	if props.Radius == 0 && props.Type == ShapeCircle {
+	if props.Layer == "" {
+		props.Layer = LayerWorld
+	}
+	if props.Radius == 0 && (props.Type == ShapeCircle || props.Type == ShapeArc) {
 		if props.Width != 0 {
 			props.Radius = props.Width / 2
 		} else if props.Height != 0 {
@@ -168,12 +228,28 @@ func NewShape(props *ShapeProps) *Shape {
 		RotationAngle:         props.Rotation,
 		RotationLock:          props.RotationLock,
 		ZIndex:                props.ZIndex,
+		Layer:                 props.Layer,
 		Scale:                 props.Scale,
 		Opacity:               props.Opacity,
 		Pattern:               props.Pattern,
 		Background:            props.Background,
 		Image:                 props.Image,
+		Gradient:              props.Gradient,
+		Tile:                  props.Tile,
+		NineSlice:             props.NineSlice,
 		Border:                props.Border,
+		Stroke:                props.Stroke,
+		CornerRadius:          props.CornerRadius,
+		ArcStart:              props.ArcStart,
+		ArcEnd:                props.ArcEnd,
+		Cursor:                props.Cursor,
+		Text:                  props.Text,
+		Font:                  props.Font,
+		FontSize:              props.FontSize,
+		FontType:              props.FontType,
+		TextAlign:             props.TextAlign,
+		LineSpacing:           props.LineSpacing,
+		WrapWidth:             props.WrapWidth,
 		IsBody:                props.IsBody,
 		Physics:               props.Physics,
 		Velocity:              props.Velocity,
@@ -183,13 +259,15 @@ func NewShape(props *ShapeProps) *Shape {
 		OnCollisionFunc:       props.OnCollisionFunc,
 		OnFinishCollisionFunc: props.OnFinishCollisionFunc,
 		Flip:                  props.Flip,
+		Nearest:               props.Nearest,
 		directions:            &Axis{},
 		Ghost:                 props.Ghost,
+		Occluder:              props.Occluder,
 		noCollideWith:         make(map[string]bool),
 		LastCollisionImpulse:  props.LastCollisionImpulse,
 	}
 
-	if props.Radius > 0 && props.Type == ShapeCircle {
+	if props.Radius > 0 && (props.Type == ShapeCircle || props.Type == ShapeArc) {
 		shape.Width = props.Radius * 2
 		shape.Height = props.Radius * 2
 	}
@@ -197,6 +275,65 @@ func NewShape(props *ShapeProps) *Shape {
 	return shape
 }
 
+func (s *Shape) loadDrawCommand(cmd *DrawCommand) {
+	var props ShapeProps
+	if cmd.Props != nil {
+		props = *cmd.Props
+	}
+	cachedMesh, cachedGradient, cachedLayout := s.mesh, s.gradientCache, s.textLayout
+
+	*s = Shape{
+		Type:          cmd.Type,
+		X:             props.X,
+		Y:             props.Y,
+		Width:         props.Width,
+		Height:        props.Height,
+		Radius:        props.Radius,
+		RotationAngle: props.Rotation,
+		ZIndex:        props.ZIndex,
+		Layer:         props.Layer,
+		Scale:         props.Scale,
+		Opacity:       props.Opacity,
+		Pattern:       props.Pattern,
+		Background:    props.Background,
+		Image:         props.Image,
+		Gradient:      props.Gradient,
+		Tile:          props.Tile,
+		NineSlice:     props.NineSlice,
+		Border:        props.Border,
+		Stroke:        props.Stroke,
+		CornerRadius:  props.CornerRadius,
+		ArcStart:      props.ArcStart,
+		ArcEnd:        props.ArcEnd,
+		Flip:          props.Flip,
+		Nearest:       props.Nearest,
+		Text:          props.Text,
+		Font:          props.Font,
+		FontSize:      props.FontSize,
+		FontType:      props.FontType,
+		TextAlign:     props.TextAlign,
+		LineSpacing:   props.LineSpacing,
+		WrapWidth:     props.WrapWidth,
+		mesh:          cachedMesh,
+		gradientCache: cachedGradient,
+		textLayout:    cachedLayout,
+	}
+
+	if s.Layer == "" {
+		s.Layer = LayerWorld
+	}
+	if s.Scale == 0 {
+		s.Scale = 1
+	}
+	if s.Opacity == 0 {
+		s.Opacity = 1
+	}
+	if s.Radius > 0 && (s.Type == ShapeCircle || s.Type == ShapeDot || s.Type == ShapeArc) {
+		s.Width = s.Radius * 2
+		s.Height = s.Radius * 2
+	}
+}
+
 func (s *Shape) Update() {
 	s.updateDirection()
 	s.updatePhysicsInfo()
@@ -309,153 +446,94 @@ func (s *Shape) SetScale(scale float64) {
 
 func (s *Shape) SetBackground(bg color.Color) {
 	s.Background = bg
-
-	s.cachedColorImage = nil
 }
 
-func (s *Shape) getColorImage(width, height int) *ebiten.Image {
-
-	if s.cachedColorImage == nil || s.lastBackground != s.Background {
-		s.cachedColorImage = ebiten.NewImage(width, height)
-		s.cachedColorImage.Fill(s.Background)
-		s.lastBackground = s.Background
-	}
-
-	return s.cachedColorImage
+func (s *Shape) Draw(screen *ebiten.Image) {
+	sharedBatch.Begin(screen)
+	s.DrawBatched(sharedBatch)
+	sharedBatch.End()
 }
 
-func (s *Shape) Draw(screen *ebiten.Image) {
+func (s *Shape) DrawBatched(b *Batch) {
 
 	if s.Opacity <= 0 {
 		return
 	}
 
-	if s.Border != nil && s.Border.Width > 0 {
-		s.drawBorder(screen)
-	}
-
-	switch s.Type {
-	case ShapeRectangle:
-		s.drawRectangle(screen)
-	case ShapeSquare:
-		s.drawSquare(screen)
-	case ShapeCircle:
-		s.drawCircle(screen)
-	case ShapeDot:
-		s.drawDot(screen)
-	case ShapeLine:
-		s.drawLine(screen)
+	filter := b.Filter
+	b.Filter = ebiten.FilterLinear
+	if s.Nearest {
+		b.Filter = ebiten.FilterNearest
 	}
-}
-
-func (s *Shape) drawRectangle(screen *ebiten.Image) {
-	op := &ebiten.DrawImageOptions{}
-
-	switch s.Pattern {
-	case PatternColor:
-
-		img := s.getColorImage(int(s.Width), int(s.Height))
-
-		s.applyTransformations(op, s.Width, s.Height)
-		screen.DrawImage(img, op)
+	defer func() { b.Filter = filter }()
 
-	case PatternImage:
-		if s.Image != nil {
-
-			imgBounds := s.Image.Bounds()
-			imgWidth := float64(imgBounds.Dx())
-			imgHeight := float64(imgBounds.Dy())
-
-			s.applyTransformations(op, imgWidth, imgHeight)
-			screen.DrawImage(s.Image, op)
-		}
+	if s.Type == ShapeText {
+		s.drawText(b)
+		return
 	}
-}
 
-func (s *Shape) drawSquare(screen *ebiten.Image) {
-	op := &ebiten.DrawImageOptions{}
+	width, height := s.drawSize()
 
-	size := s.Width
-	if s.Height > s.Width {
-		size = s.Height
+	if s.needsMesh() {
+		s.drawMesh(b, width, height)
+		return
 	}
 
 	switch s.Pattern {
 	case PatternColor:
-
-		img := s.getColorImage(int(size), int(size))
-
-		s.applyTransformations(op, size, size)
-		screen.DrawImage(img, op)
+		var geoM ebiten.GeoM
+		s.applyTransformations(&geoM, width, height)
+		b.FillRect(&geoM, width, height, s.Background, s.Opacity)
 
 	case PatternImage:
-		if s.Image != nil {
-			imgBounds := s.Image.Bounds()
-			imgWidth := float64(imgBounds.Dx())
-			imgHeight := float64(imgBounds.Dy())
+		s.drawImage(b)
 
-			s.applyTransformations(op, imgWidth, imgHeight)
-			screen.DrawImage(s.Image, op)
-		}
+	case PatternNineSlice:
+		var geoM ebiten.GeoM
+		s.applyTransformations(&geoM, width, height)
+		s.drawNineSlice(b, &geoM, width, height)
 	}
 }
 
-func (s *Shape) drawCircle(screen *ebiten.Image) {
-	op := &ebiten.DrawImageOptions{}
-
-	switch s.Pattern {
-	case PatternColor:
-
-		size := int(s.Radius * 2)
-		img := ebiten.NewImage(size, size)
-
-		for y := 0; y < size; y++ {
-			for x := 0; x < size; x++ {
-				dx := float64(x) - s.Radius
-				dy := float64(y) - s.Radius
-				if dx*dx+dy*dy <= s.Radius*s.Radius {
-					img.Set(x, y, s.Background)
-				}
-			}
-		}
-
-		s.applyTransformations(op, s.Radius*2, s.Radius*2)
-		screen.DrawImage(img, op)
-
-	case PatternImage:
-		if s.Image != nil {
-			imgBounds := s.Image.Bounds()
-			imgWidth := float64(imgBounds.Dx())
-			imgHeight := float64(imgBounds.Dy())
-
-			s.applyTransformations(op, imgWidth, imgHeight)
-			screen.DrawImage(s.Image, op)
-		}
+func (s *Shape) drawSize() (float64, float64) {
+	switch s.Type {
+	case ShapeSquare:
+		size := math.Max(s.Width, s.Height)
+		return size, size
+	case ShapeCircle, ShapeDot, ShapeArc:
+		return s.Radius * 2, s.Radius * 2
 	}
+	return s.Width, s.Height
 }
 
-func (s *Shape) drawLine(screen *ebiten.Image) {
-	op := &ebiten.DrawImageOptions{}
-
-	img := ebiten.NewImage(int(s.Width), int(s.Height))
-	img.Fill(s.Background)
-
-	s.applyTransformations(op, s.Width, s.Height)
-	screen.DrawImage(img, op)
-}
+func (s *Shape) needsMesh() bool {
+	if s.Pattern == PatternGradient || s.Pattern == PatternTile {
+		return true
+	}
 
-func (s *Shape) drawDot(screen *ebiten.Image) {
-	s.drawCircle(screen)
+	switch s.Type {
+	case ShapeRectangle, ShapeSquare, ShapeLine:
+		return s.CornerRadius > 0 || s.RotationAngle != 0 ||
+			(s.Stroke != nil && s.Stroke.Width > 0) ||
+			(s.Border != nil && s.Border.Width > 0)
+	}
+	return true
 }
 
-func (s *Shape) applyTransformations(op *ebiten.DrawImageOptions, originalWidth, originalHeight float64) {
+func (s *Shape) drawImage(b *Batch) {
+	if s.Image == nil {
+		return
+	}
 
-	op.GeoM.Translate(-originalWidth/2, -originalHeight/2)
+	var geoM ebiten.GeoM
+	imgBounds := s.Image.Bounds()
+	s.applyTransformations(&geoM, float64(imgBounds.Dx()), float64(imgBounds.Dy()))
+	b.DrawImage(s.Image, &geoM, s.Opacity)
+}
 
-	// scale := ebiten.Monitor().DeviceScaleFactor()
-	// op.GeoM.Scale(scale, scale)
+func (s *Shape) applyTransformations(geoM *ebiten.GeoM, originalWidth, originalHeight float64) {
 
-	op.Filter = ebiten.FilterLinear
+	geoM.Translate(-originalWidth/2, -originalHeight/2)
 
 	scaleX, scaleY := 1.0, 1.0
 	if s.Flip.X {
@@ -474,25 +552,18 @@ func (s *Shape) applyTransformations(op *ebiten.DrawImageOptions, originalWidth,
 	scaleX *= s.Scale
 	scaleY *= s.Scale
 
-	op.GeoM.Scale(scaleX, scaleY)
-
-	op.GeoM.Rotate(s.RotationAngle)
+	geoM.Scale(scaleX, scaleY)
 
-	op.GeoM.Translate(s.X+s.Width/2, s.Y+s.Height/2)
+	geoM.Rotate(s.RotationAngle)
 
-	if s.Opacity < 1.0 {
-		op.ColorScale.Scale(1, 1, 1, float32(s.Opacity))
-	}
+	geoM.Translate(s.X+s.Width/2, s.Y+s.Height/2)
+	s.applyCamera(geoM)
 }
 
-func (s *Shape) drawBorder(screen *ebiten.Image) {
-
-	borderImg := ebiten.NewImage(int(s.Width+s.Border.Width*2), int(s.Height+s.Border.Width*2))
-	borderImg.Fill(s.Border.Background)
-
-	op := &ebiten.DrawImageOptions{}
-	op.GeoM.Translate(s.X-s.Border.Width, s.Y-s.Border.Width)
-	screen.DrawImage(borderImg, op)
+func (s *Shape) applyCamera(geoM *ebiten.GeoM) {
+	if s.world != nil && !s.world.isScreenSpace(s.Layer) {
+		geoM.Translate(-s.world.Camera.X, -s.world.Camera.Y)
+	}
 }
 
 func (s *Shape) MoveTheta(angle float64, optionalSpeed ...float64) {
@@ -1,12 +1,39 @@
 package life
 
 import (
+	"embed"
+	"fmt"
 	"image/color"
+	"math"
+	"os"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+	"unicode/utf8"
 
+	"github.com/ByteArena/box2d"
 	"github.com/hajimehoshi/ebiten/v2"
 	"github.com/hajimehoshi/ebiten/v2/text"
 	"golang.org/x/image/font"
 	"golang.org/x/image/font/basicfont"
+	"golang.org/x/image/font/opentype"
+	"golang.org/x/image/math/fixed"
+)
+
+type TextAlign string
+
+const (
+	AlignLeft     TextAlign = "left"
+	AlignCenter   TextAlign = "center"
+	AlignRight    TextAlign = "right"
+	AlignTop      TextAlign = "top"
+	AlignMiddle   TextAlign = "middle"
+	AlignBottom   TextAlign = "bottom"
+	AlignBaseline TextAlign = "baseline"
+
+	DefaultFontSize = 16.0
+	DefaultFontDPI  = 72.0
 )
 
 type TextProps struct {
@@ -17,6 +44,364 @@ type TextProps struct {
 	Size    float64
 	FromEnd bool
 	Type    string
+
+	Align         TextAlign
+	VerticalAlign TextAlign
+	MaxWidth      float64
+	LineSpacing   float64
+
+	Markup         bool
+	BoldFont       font.Face
+	ItalicFont     font.Face
+	BoldItalicFont font.Face
+
+	Outline    *TextOutline
+	Shadow     *TextShadow
+	GlyphColor func(index int, r rune, x, y float64) color.Color
+	Gradient   *Gradient
+	Typewriter *Typewriter
+
+	// Time drives the wave and shake markup effects. World.DrawText fills it
+	// from the world's game time.
+	Time time.Duration
+}
+
+type TextOutline struct {
+	Width float64
+	Color color.Color
+}
+
+type TextShadow struct {
+	Offset Vector2
+	Color  color.Color
+}
+
+type FontProps struct {
+	Size    float64
+	DPI     float64
+	Hinting font.Hinting
+}
+
+type TextLine struct {
+	Text  string
+	X, Y  float64
+	Width float64
+}
+
+type TextLayout struct {
+	Lines      []TextLine
+	X, Y       float64
+	Width      float64
+	Height     float64
+	LineHeight float64
+	Ascent     float64
+	Descent    float64
+}
+
+type fontKey struct {
+	name string
+	size float64
+}
+
+var (
+	fontMutex   sync.RWMutex
+	fonts       = map[string]*opentype.Font{}
+	fontFaces   = map[fontKey]font.Face{}
+	bitmapFonts = map[string]*BitmapFont{}
+)
+
+func ParseFont(data []byte) (*opentype.Font, error) {
+	f, err := opentype.Parse(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse font: %w", err)
+	}
+	return f, nil
+}
+
+func NewFontFace(f *opentype.Font, props *FontProps) (font.Face, error) {
+	if props == nil {
+		props = &FontProps{}
+	}
+
+	if props.Size == 0 {
+		props.Size = DefaultFontSize
+	}
+	if props.DPI == 0 {
+		props.DPI = DefaultFontDPI
+	}
+
+	face, err := opentype.NewFace(f, &opentype.FaceOptions{
+		Size:    props.Size,
+		DPI:     props.DPI,
+		Hinting: props.Hinting,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create font face: %w", err)
+	}
+	return face, nil
+}
+
+func LoadFontFace(data []byte, props *FontProps) (font.Face, error) {
+	f, err := ParseFont(data)
+	if err != nil {
+		return nil, err
+	}
+	return NewFontFace(f, props)
+}
+
+func LoadFont(path string, size float64) (font.Face, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read font %s: %w", path, err)
+	}
+	return LoadFontFace(data, &FontProps{Size: size})
+}
+
+func LoadFontFromFS(fs embed.FS, path string, size float64) (font.Face, error) {
+	data, err := fs.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read font %s: %w", path, err)
+	}
+	return LoadFontFace(data, &FontProps{Size: size})
+}
+
+func RegisterFont(name string, data []byte) error {
+	f, err := ParseFont(data)
+	if err != nil {
+		return fmt.Errorf("failed to register font %s: %w", name, err)
+	}
+
+	fontMutex.Lock()
+	defer fontMutex.Unlock()
+
+	fonts[name] = f
+	delete(bitmapFonts, name)
+	for key, face := range fontFaces {
+		if key.name == name {
+			face.Close()
+			delete(fontFaces, key)
+		}
+	}
+	return nil
+}
+
+func RegisterFontFile(name, path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read font %s: %w", path, err)
+	}
+	return RegisterFont(name, data)
+}
+
+func RegisterFontFromFS(name string, fs embed.FS, path string) error {
+	data, err := fs.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read font %s: %w", path, err)
+	}
+	return RegisterFont(name, data)
+}
+
+func GetFont(name string, size float64) (font.Face, error) {
+	if size <= 0 {
+		size = DefaultFontSize
+	}
+	key := fontKey{name: name, size: size}
+
+	fontMutex.RLock()
+	face, ok := fontFaces[key]
+	f := fonts[name]
+	bf := bitmapFonts[name]
+	fontMutex.RUnlock()
+
+	if bf != nil {
+		return bf, nil
+	}
+	if ok {
+		return face, nil
+	}
+	if f == nil {
+		return nil, fmt.Errorf("font %s is not registered", name)
+	}
+
+	face, err := NewFontFace(f, &FontProps{Size: size})
+	if err != nil {
+		return nil, err
+	}
+
+	fontMutex.Lock()
+	defer fontMutex.Unlock()
+	if existing, ok := fontFaces[key]; ok {
+		face.Close()
+		return existing, nil
+	}
+	fontFaces[key] = face
+	return face, nil
+}
+
+// Face resolves the face text is drawn with. When Type names a font that is
+// not registered, it returns the default face along with the lookup error.
+func (props *TextProps) Face() (font.Face, error) {
+	if props.Font != nil {
+		return props.Font, nil
+	}
+	if props.Type != "" {
+		face, err := GetFont(props.Type, props.Size)
+		if err != nil {
+			return basicfont.Face7x13, err
+		}
+		return face, nil
+	}
+	return basicfont.Face7x13, nil
+}
+
+func (props *TextProps) face() font.Face {
+	face, _ := props.Face()
+	return face
+}
+
+func fixedToFloat(v fixed.Int26_6) float64 {
+	return float64(v) / 64
+}
+
+func measureString(face font.Face, s string) float64 {
+	return fixedToFloat(font.MeasureString(face, s))
+}
+
+func wrapLine(face font.Face, line string, maxWidth float64, lines []string) []string {
+	if maxWidth <= 0 || measureString(face, line) <= maxWidth {
+		return append(lines, line)
+	}
+
+	current := ""
+	for _, word := range strings.Fields(line) {
+		candidate := word
+		if current != "" {
+			candidate = current + " " + word
+		}
+		if measureString(face, candidate) <= maxWidth {
+			current = candidate
+			continue
+		}
+
+		if current != "" {
+			lines = append(lines, current)
+			current = ""
+		}
+
+		for measureString(face, word) > maxWidth && utf8.RuneCountInString(word) > 1 {
+			split := breakWord(face, word, maxWidth)
+			lines = append(lines, word[:split])
+			word = word[split:]
+		}
+		current = word
+	}
+
+	return append(lines, current)
+}
+
+func breakWord(face font.Face, word string, maxWidth float64) int {
+	split := 0
+	for i, r := range word {
+		end := i + utf8.RuneLen(r)
+		if split > 0 && measureString(face, word[:end]) > maxWidth {
+			break
+		}
+		split = end
+	}
+	return split
+}
+
+func LayoutText(screen *ebiten.Image, props *TextProps) TextLayout {
+	if props == nil {
+		return TextLayout{}
+	}
+	if props.Markup {
+		layout := layoutRichText(screen, props)
+		return layout.textLayout()
+	}
+
+	face := props.face()
+	metrics := face.Metrics()
+	ascent := fixedToFloat(metrics.Ascent)
+	descent := fixedToFloat(metrics.Descent)
+
+	spacing := props.LineSpacing
+	if spacing == 0 {
+		spacing = 1
+	}
+	lineHeight := fixedToFloat(metrics.Height) * spacing
+
+	var lines []string
+	for _, paragraph := range strings.Split(strings.ReplaceAll(props.Text, "\r\n", "\n"), "\n") {
+		lines = wrapLine(face, paragraph, props.MaxWidth, lines)
+	}
+
+	layout := TextLayout{
+		Lines:      make([]TextLine, len(lines)),
+		LineHeight: lineHeight,
+		Ascent:     ascent,
+		Descent:    descent,
+		Height:     ascent + descent + lineHeight*float64(len(lines)-1),
+	}
+	for i, line := range lines {
+		width := measureString(face, line)
+		layout.Lines[i] = TextLine{Text: line, Width: width}
+		layout.Width = math.Max(layout.Width, width)
+	}
+
+	anchorX := props.X
+	align := props.Align
+	if props.FromEnd && screen != nil {
+		anchorX = float64(screen.Bounds().Dx()) - props.X
+		if align == "" {
+			align = AlignRight
+		}
+	}
+
+	baseline := props.Y
+	switch props.VerticalAlign {
+	case AlignTop:
+		baseline = props.Y + ascent
+	case AlignMiddle:
+		baseline = props.Y - layout.Height/2 + ascent
+	case AlignBottom:
+		baseline = props.Y - layout.Height + ascent
+	}
+
+	switch align {
+	case AlignCenter:
+		layout.X = anchorX - layout.Width/2
+	case AlignRight:
+		layout.X = anchorX - layout.Width
+	default:
+		layout.X = anchorX
+	}
+	layout.Y = baseline - ascent
+
+	for i := range layout.Lines {
+		line := &layout.Lines[i]
+		switch align {
+		case AlignCenter:
+			line.X = anchorX - line.Width/2
+		case AlignRight:
+			line.X = anchorX - line.Width
+		default:
+			line.X = anchorX
+		}
+		line.Y = baseline + lineHeight*float64(i)
+	}
+
+	return layout
+}
+
+func MeasureText(props *TextProps) (width, height float64) {
+	layout := LayoutText(nil, props)
+	return layout.Width, layout.Height
+}
+
+func TextBounds(screen *ebiten.Image, props *TextProps) (x, y, width, height float64) {
+	layout := LayoutText(screen, props)
+	return layout.X, layout.Y, layout.Width, layout.Height
 }
 
 func DrawText(screen *ebiten.Image, props *TextProps) {
@@ -24,9 +409,6 @@ func DrawText(screen *ebiten.Image, props *TextProps) {
 		return
 	}
 
-	if props.Font == nil {
-		props.Font = basicfont.Face7x13
-	}
 	if props.Color == nil {
 		props.Color = color.RGBA{255, 255, 255, 255}
 	}
@@ -34,19 +416,278 @@ func DrawText(screen *ebiten.Image, props *TextProps) {
 		return
 	}
 
-	x := int(props.X)
-	y := int(props.Y)
+	if props.Markup {
+		layout := layoutRichText(screen, props)
+		drawRichLayout(screen, props, &layout, nil, 1)
+		return
+	}
 
-	if props.FromEnd {
+	layout := LayoutText(screen, props)
+	drawTextLayout(screen, props, &layout, nil, 1)
+}
 
-		bounds, _ := font.BoundString(props.Font, props.Text)
-		x = int(float64(screen.Bounds().Dx()) - float64(bounds.Max.X) - props.X)
+func (w *World) DrawText(screen *ebiten.Image, props *TextProps) {
+	if props == nil {
+		return
 	}
 
-	text.Draw(screen, props.Text, props.Font, x, y, props.Color)
+	timed := *props
+	timed.Time = w.GameTime()
+	DrawText(screen, &timed)
 }
 
-func LoadFont(path string, size float64) (font.Face, error) {
+type textPass struct {
+	target    *ebiten.Image
+	face      font.Face
+	layout    *TextLayout
+	transform *ebiten.GeoM
+	opacity   float64
+	visible   int
+	options   ebiten.DrawImageOptions
+}
 
-	return basicfont.Face7x13, nil
+func drawTextLayout(target *ebiten.Image, props *TextProps, layout *TextLayout, transform *ebiten.GeoM, opacity float64) {
+	pass := &textPass{
+		target:    target,
+		face:      props.face(),
+		layout:    layout,
+		transform: transform,
+		opacity:   opacity,
+		visible:   -1,
+	}
+	if props.Typewriter != nil {
+		pass.visible = props.Typewriter.Visible()
+		if pass.visible == 0 {
+			return
+		}
+	}
+
+	if shadow := props.Shadow; shadow != nil {
+		offset := shadow.Offset
+		if offset == (Vector2{}) {
+			offset = Vector2{X: 2, Y: 2}
+		}
+		shadowColor := shadow.Color
+		if shadowColor == nil {
+			shadowColor = color.RGBA{0, 0, 0, 160}
+		}
+		pass.draw(offset.X, offset.Y, shadowColor, nil)
+	}
+
+	if outline := props.Outline; outline != nil && outline.Width > 0 {
+		outlineColor := outline.Color
+		if outlineColor == nil {
+			outlineColor = color.RGBA{0, 0, 0, 255}
+		}
+		steps := int(math.Min(32, math.Max(8, math.Ceil(2*math.Pi*outline.Width))))
+		for i := 0; i < steps; i++ {
+			angle := float64(i) / float64(steps) * 2 * math.Pi
+			pass.draw(math.Cos(angle)*outline.Width, math.Sin(angle)*outline.Width, outlineColor, nil)
+		}
+	}
+
+	glyphColor := props.GlyphColor
+	if glyphColor == nil && props.Gradient != nil && len(props.Gradient.Stops) > 0 {
+		glyphColor = gradientGlyphColor(props.Gradient, layout)
+	}
+	pass.draw(0, 0, props.Color, glyphColor)
+}
+
+func gradientGlyphColor(g *Gradient, layout *TextLayout) func(index int, r rune, x, y float64) color.Color {
+	sorted := g.sortedStops()
+	return func(_ int, _ rune, x, y float64) color.Color {
+		r, gr, b, a := g.colorAt(g.position(x-layout.X, y-layout.Y, layout.Width, layout.Height), sorted)
+		return color.NRGBA{uint8(r * 255), uint8(gr * 255), uint8(b * 255), uint8(a * 255)}
+	}
+}
+
+func (p *textPass) draw(dx, dy float64, c color.Color, glyphColor func(index int, r rune, x, y float64) color.Color) {
+	budget := p.visible
+	index := 0
+
+	for _, line := range p.layout.Lines {
+		if budget == 0 {
+			return
+		}
+
+		if glyphColor == nil {
+			value := line.Text
+			if budget > 0 {
+				var used int
+				value, used = cutGlyphs(value, budget)
+				budget -= used
+			}
+			p.drawString(value, line.X+dx, line.Y+dy, c)
+			continue
+		}
+
+		x := line.X
+		previous := rune(-1)
+		for _, r := range line.Text {
+			if previous >= 0 {
+				x += fixedToFloat(p.face.Kern(previous, r))
+			}
+			advance, _ := p.face.GlyphAdvance(r)
+			width := fixedToFloat(advance)
+
+			if !unicode.IsSpace(r) {
+				if budget == 0 {
+					return
+				}
+				center := line.Y - p.layout.Ascent/2
+				p.drawString(string(r), x+dx, line.Y+dy, glyphColor(index, r, x+width/2, center))
+				index++
+				if budget > 0 {
+					budget--
+				}
+			}
+
+			x += width
+			previous = r
+		}
+	}
+}
+
+func (p *textPass) drawString(value string, x, y float64, c color.Color) {
+	if value == "" || c == nil {
+		return
+	}
+
+	op := &p.options
+	op.GeoM.Reset()
+	op.GeoM.Translate(math.Round(x), math.Round(y))
+	if p.transform != nil {
+		op.GeoM.Concat(*p.transform)
+	}
+	op.ColorScale.Reset()
+	op.ColorScale.ScaleWithColor(c)
+	op.ColorScale.ScaleAlpha(float32(p.opacity))
+	op.Filter = ebiten.FilterLinear
+	text.DrawWithOptions(p.target, value, p.face, op)
+	countDrawCall()
+}
+
+func cutGlyphs(value string, count int) (string, int) {
+	used := 0
+	for i, r := range value {
+		if unicode.IsSpace(r) {
+			continue
+		}
+		if used == count {
+			return value[:i], used
+		}
+		used++
+	}
+	return value, used
+}
+
+type textLayoutCache struct {
+	props  TextProps
+	layout TextLayout
+}
+
+func (s *Shape) textProps() TextProps {
+	props := TextProps{
+		Text:          s.Text,
+		Color:         s.Background,
+		Font:          s.Font,
+		Size:          s.FontSize,
+		Type:          s.FontType,
+		Align:         s.TextAlign,
+		VerticalAlign: AlignTop,
+		MaxWidth:      s.WrapWidth,
+		LineSpacing:   s.LineSpacing,
+	}
+
+	switch s.TextAlign {
+	case AlignCenter:
+		props.X = s.Width / 2
+	case AlignRight:
+		props.X = s.Width
+	}
+	return props
+}
+
+func sameTextLayout(a, b *TextProps) bool {
+	return a.Text == b.Text && a.X == b.X && a.Y == b.Y && a.Font == b.Font &&
+		a.Size == b.Size && a.Type == b.Type && a.Align == b.Align &&
+		a.VerticalAlign == b.VerticalAlign && a.MaxWidth == b.MaxWidth &&
+		a.LineSpacing == b.LineSpacing
+}
+
+func (s *Shape) getTextLayout() *TextLayout {
+	props := s.textProps()
+	if s.textLayout != nil && sameTextLayout(&s.textLayout.props, &props) {
+		s.textLayout.props.Color = props.Color
+		return &s.textLayout.layout
+	}
+
+	if s.textLayout == nil {
+		s.textLayout = &textLayoutCache{}
+	}
+	s.textLayout.props = props
+	s.textLayout.layout = LayoutText(nil, &props)
+	return &s.textLayout.layout
+}
+
+func (s *Shape) SetText(value string) {
+	s.Text = value
+	s.FitText()
+}
+
+func measureShapeText(props *ShapeProps) (float64, float64) {
+	width, height := MeasureText(&TextProps{
+		Text:          props.Text,
+		Font:          props.Font,
+		Size:          props.FontSize,
+		Type:          props.FontType,
+		VerticalAlign: AlignTop,
+		MaxWidth:      props.WrapWidth,
+		LineSpacing:   props.LineSpacing,
+	})
+	return math.Max(1, width), math.Max(1, height)
+}
+
+func (s *Shape) FitText() {
+	x, y := s.X, s.Y
+	props := s.textProps()
+	width, height := MeasureText(&props)
+	s.Width = math.Max(1, width)
+	s.Height = math.Max(1, height)
+
+	if s.Body == nil {
+		return
+	}
+
+	fixture := s.Body.GetFixtureList()
+	if fixture == nil {
+		return
+	}
+
+	box := box2d.MakeB2PolygonShape()
+	box.SetAsBox(PixelsToMeters(s.Width/2), PixelsToMeters(s.Height/2))
+	s.Body.CreateFixtureFromDef(&box2d.B2FixtureDef{
+		Shape:       &box,
+		Friction:    fixture.GetFriction(),
+		Restitution: fixture.GetRestitution(),
+		Density:     fixture.GetDensity(),
+		IsSensor:    fixture.IsSensor(),
+		Filter:      fixture.GetFilterData(),
+	})
+	s.Body.DestroyFixture(fixture)
+	s.SetPosition(x, y)
+}
+
+func (s *Shape) drawText(b *Batch) {
+	if s.Text == "" || b.target == nil {
+		return
+	}
+
+	layout := s.getTextLayout()
+
+	b.Flush()
+
+	var geoM ebiten.GeoM
+	s.applyTransformations(&geoM, s.Width, s.Height)
+	drawTextLayout(b.target, &s.textLayout.props, layout, &geoM, s.Opacity)
 }
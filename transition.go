@@ -0,0 +1,238 @@
+package life
+
+import (
+	"image"
+	"image/color"
+	"math"
+	"time"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/vector"
+)
+
+type TransitionType string
+
+const (
+	TransitionNone      TransitionType = "none"
+	TransitionFade      TransitionType = "fade"
+	TransitionCrossfade TransitionType = "crossfade"
+	TransitionWipe      TransitionType = "wipe"
+	TransitionIris      TransitionType = "iris"
+	TransitionPixelate  TransitionType = "pixelate"
+
+	maxPixelateBlock = 32
+)
+
+type WipeDirection string
+
+const (
+	WipeLeft  WipeDirection = "left"
+	WipeRight WipeDirection = "right"
+	WipeUp    WipeDirection = "up"
+	WipeDown  WipeDirection = "down"
+)
+
+type Transition struct {
+	Type      TransitionType
+	Duration  time.Duration
+	Easing    EasingFunc
+	Color     color.Color
+	Direction WipeDirection
+}
+
+type levelTransition struct {
+	Transition
+	target   int
+	elapsed  float64
+	switched bool
+	captured bool
+}
+
+func (w *World) startTransition(levelIndex int, transition Transition) {
+	if transition.Type == "" || transition.Type == TransitionNone || transition.Duration <= 0 {
+		w.pendingLevelSwitch = &levelIndex
+		return
+	}
+
+	if transition.Easing == nil {
+		transition.Easing = EaseInOutQuad
+	}
+	if transition.Color == nil {
+		transition.Color = color.RGBA{0, 0, 0, 255}
+	}
+	if transition.Direction == "" {
+		transition.Direction = WipeRight
+	}
+
+	w.transition = &levelTransition{
+		Transition: transition,
+		target:     levelIndex,
+	}
+}
+
+func (w *World) IsTransitioning() bool {
+	return w.transition != nil
+}
+
+func (w *World) updateTransition(delta float64) {
+	t := w.transition
+	if t == nil {
+		return
+	}
+
+	if t.Type == TransitionCrossfade {
+		if !t.captured {
+			return
+		}
+		if !t.switched {
+			t.switched = true
+			w.SelectLevel(t.target)
+			return
+		}
+	}
+
+	t.elapsed += delta
+	progress := t.elapsed / t.Duration.Seconds()
+
+	if !t.switched && progress >= 0.5 {
+		t.switched = true
+		w.SelectLevel(t.target)
+	}
+
+	if progress >= 1 {
+		w.transition = nil
+		if w.transitionSnapshot != nil {
+			w.transitionSnapshot.Deallocate()
+			w.transitionSnapshot = nil
+		}
+	}
+}
+
+func (t *levelTransition) amount() float64 {
+	progress := math.Min(1, t.elapsed/t.Duration.Seconds())
+	if t.Type == TransitionCrossfade {
+		return 1 - t.Easing(progress)
+	}
+	if !t.switched {
+		return t.Easing(math.Min(1, progress*2))
+	}
+	return t.Easing(math.Max(0, 1-(progress-0.5)*2))
+}
+
+func (w *World) drawTransition(screen *ebiten.Image) {
+	t := w.transition
+	if t == nil {
+		return
+	}
+
+	amount := t.amount()
+	bounds := screen.Bounds()
+	width, height := float32(bounds.Dx()), float32(bounds.Dy())
+
+	switch t.Type {
+	case TransitionFade:
+		r, g, b, a := straightRGBA(t.Color)
+		screen.DrawImage(whiteSubImage, fillOptions(width, height, r, g, b, a*amount))
+		countDrawCall()
+
+	case TransitionCrossfade:
+		if !t.captured {
+			if w.transitionSnapshot == nil || w.transitionSnapshot.Bounds() != bounds {
+				w.transitionSnapshot = ebiten.NewImage(bounds.Dx(), bounds.Dy())
+			}
+			w.transitionSnapshot.Clear()
+			w.transitionSnapshot.DrawImage(screen, nil)
+			countDrawCall()
+			t.captured = true
+			return
+		}
+		if w.transitionSnapshot != nil {
+			op := &ebiten.DrawImageOptions{}
+			op.ColorScale.ScaleAlpha(float32(amount))
+			screen.DrawImage(w.transitionSnapshot, op)
+			countDrawCall()
+		}
+
+	case TransitionWipe:
+		x, y, w, h := wipeRect(t.Direction, t.switched, float32(amount), width, height)
+		vector.DrawFilledRect(screen, x, y, w, h, t.Color, false)
+		countDrawCall()
+
+	case TransitionIris:
+		maxRadius := math.Hypot(float64(width), float64(height)) / 2
+		radius := float32(maxRadius * (1 - amount))
+
+		var path vector.Path
+		path.MoveTo(0, 0)
+		path.LineTo(width, 0)
+		path.LineTo(width, height)
+		path.LineTo(0, height)
+		path.Close()
+		path.MoveTo(width/2+radius, height/2)
+		path.Arc(width/2, height/2, radius, 0, 2*math.Pi, vector.Clockwise)
+		path.Close()
+
+		vertices, indices := path.AppendVerticesAndIndicesForFilling(nil, nil)
+		r, g, b, a := premultiply(t.Color, 1)
+		for i := range vertices {
+			vertices[i].SrcX, vertices[i].SrcY = 1.5, 1.5
+			vertices[i].ColorR, vertices[i].ColorG, vertices[i].ColorB, vertices[i].ColorA = r, g, b, a
+		}
+		screen.DrawTriangles(vertices, indices, whiteSubImage, &ebiten.DrawTrianglesOptions{
+			ColorScaleMode: ebiten.ColorScaleModePremultipliedAlpha,
+			FillRule:       ebiten.FillRuleEvenOdd,
+			AntiAlias:      true,
+		})
+		countDrawCall()
+
+	case TransitionPixelate:
+		block := 1 + amount*(maxPixelateBlock-1)
+		if block <= 1 {
+			return
+		}
+
+		smallW := int(math.Max(1, float64(width)/block))
+		smallH := int(math.Max(1, float64(height)/block))
+		if w.transitionSnapshot == nil || w.transitionSnapshot.Bounds() != bounds {
+			w.transitionSnapshot = ebiten.NewImage(bounds.Dx(), bounds.Dy())
+		}
+
+		w.transitionSnapshot.Clear()
+		op := &ebiten.DrawImageOptions{}
+		op.GeoM.Scale(float64(smallW)/float64(width), float64(smallH)/float64(height))
+		op.Filter = ebiten.FilterLinear
+		w.transitionSnapshot.DrawImage(screen, op)
+		countDrawCall()
+
+		small := w.transitionSnapshot.SubImage(image.Rect(0, 0, smallW, smallH)).(*ebiten.Image)
+		op = &ebiten.DrawImageOptions{}
+		op.GeoM.Scale(float64(width)/float64(smallW), float64(height)/float64(smallH))
+		op.Filter = ebiten.FilterNearest
+		op.Blend = ebiten.BlendCopy
+		screen.DrawImage(small, op)
+		countDrawCall()
+	}
+}
+
+func wipeRect(direction WipeDirection, incoming bool, amount, width, height float32) (x, y, w, h float32) {
+	switch direction {
+	case WipeLeft, WipeRight:
+		w, h = width*amount, height
+		if (direction == WipeLeft) != incoming {
+			x = width - w
+		}
+	default:
+		w, h = width, height*amount
+		if (direction == WipeUp) != incoming {
+			y = height - h
+		}
+	}
+	return x, y, w, h
+}
+
+func fillOptions(width, height float32, r, g, b, a float64) *ebiten.DrawImageOptions {
+	op := &ebiten.DrawImageOptions{}
+	op.GeoM.Scale(float64(width), float64(height))
+	op.ColorScale.Scale(float32(r*a), float32(g*a), float32(b*a), float32(a))
+	return op
+}
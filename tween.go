@@ -0,0 +1,548 @@
+package life
+
+import (
+	"image/color"
+	"math"
+	"reflect"
+	"time"
+
+	"github.com/ByteArena/box2d"
+)
+
+type TweenProps map[string]interface{}
+
+type tweenChannel struct {
+	get  func() []float64
+	set  func([]float64)
+	to   []float64
+	from []float64
+}
+
+type tweenStep struct {
+	duration float64
+	easing   EasingFunc
+	props    TweenProps
+	floats   map[*float64]float64
+	channels []*tweenChannel
+	captured bool
+	call     func()
+	fired    bool
+	group    []*Tweener
+}
+
+type Tweener struct {
+	Target *Shape
+	Speed  float64
+
+	world      *World
+	steps      []*tweenStep
+	repeat     int
+	yoyo       bool
+	time       float64
+	playing    bool
+	finished   bool
+	registered bool
+	owned      bool
+	onComplete func(*Shape)
+	onUpdate   func(*Shape)
+	onRepeat   func(*Shape)
+}
+
+// Tween animates target from its world's loop. Tweens without a target have no
+// loop to run in; create those with World.Tween or call Update yourself.
+func Tween(target *Shape) *Tweener {
+	return newTween(target, nil)
+}
+
+func (w *World) Tween(target *Shape) *Tweener {
+	return newTween(target, w)
+}
+
+func newTween(target *Shape, world *World) *Tweener {
+	t := &Tweener{
+		Target:  target,
+		Speed:   1,
+		world:   world,
+		playing: true,
+	}
+	t.register()
+	return t
+}
+
+func Parallel(tweens ...*Tweener) *Tweener {
+	return newTween(parallelTarget(tweens), nil).Parallel(tweens...)
+}
+
+func (w *World) Parallel(tweens ...*Tweener) *Tweener {
+	return newTween(parallelTarget(tweens), w).Parallel(tweens...)
+}
+
+func parallelTarget(tweens []*Tweener) *Shape {
+	for _, child := range tweens {
+		if child.Target != nil {
+			return child.Target
+		}
+	}
+	return nil
+}
+
+func (t *Tweener) register() {
+	if t.registered || t.owned {
+		return
+	}
+
+	switch {
+	case t.Target != nil:
+		t.registered = true
+		t.Target.tweens = append(t.Target.tweens, t)
+	case t.world != nil:
+		t.registered = true
+		t.world.tweens = append(t.world.tweens, t)
+	}
+}
+
+func (t *Tweener) To(props TweenProps, duration time.Duration, easing EasingFunc) *Tweener {
+	t.steps = append(t.steps, &tweenStep{duration: duration.Seconds(), easing: easing, props: props})
+	return t
+}
+
+func (t *Tweener) ToFloat(value *float64, to float64, duration time.Duration, easing EasingFunc) *Tweener {
+	t.steps = append(t.steps, &tweenStep{
+		duration: duration.Seconds(),
+		easing:   easing,
+		floats:   map[*float64]float64{value: to},
+	})
+	return t
+}
+
+func (t *Tweener) Delay(duration time.Duration) *Tweener {
+	t.steps = append(t.steps, &tweenStep{duration: duration.Seconds()})
+	return t
+}
+
+func (t *Tweener) Call(callback func()) *Tweener {
+	t.steps = append(t.steps, &tweenStep{call: callback})
+	return t
+}
+
+func (t *Tweener) Parallel(tweens ...*Tweener) *Tweener {
+	for _, child := range tweens {
+		child.owned = true
+		child.playing = true
+	}
+	t.steps = append(t.steps, &tweenStep{group: tweens})
+	return t
+}
+
+func (t *Tweener) Repeat(count int) *Tweener {
+	t.repeat = count
+	return t
+}
+
+func (t *Tweener) Yoyo(yoyo bool) *Tweener {
+	t.yoyo = yoyo
+	return t
+}
+
+func (t *Tweener) SetSpeed(speed float64) *Tweener {
+	t.Speed = speed
+	return t
+}
+
+func (t *Tweener) OnComplete(callback func(*Shape)) *Tweener {
+	t.onComplete = callback
+	return t
+}
+
+func (t *Tweener) OnUpdate(callback func(*Shape)) *Tweener {
+	t.onUpdate = callback
+	return t
+}
+
+func (t *Tweener) OnRepeat(callback func(*Shape)) *Tweener {
+	t.onRepeat = callback
+	return t
+}
+
+func (t *Tweener) Pause() *Tweener {
+	t.playing = false
+	return t
+}
+
+func (t *Tweener) Resume() *Tweener {
+	if !t.finished {
+		t.playing = true
+		t.register()
+	}
+	return t
+}
+
+func (t *Tweener) Stop() *Tweener {
+	t.playing = false
+	t.finished = true
+	return t
+}
+
+func (t *Tweener) Restart() *Tweener {
+	t.seek(0)
+	t.finished = false
+	for _, step := range t.steps {
+		step.fired = false
+	}
+	return t.Resume()
+}
+
+func (t *Tweener) IsPlaying() bool {
+	return t.playing
+}
+
+func (t *Tweener) Finished() bool {
+	return t.finished
+}
+
+func (t *Tweener) Duration() time.Duration {
+	total := t.total()
+	if math.IsInf(total, 1) {
+		return time.Duration(math.MaxInt64)
+	}
+	return time.Duration(total * float64(time.Second))
+}
+
+func (t *Tweener) Update(delta float64) {
+	if !t.playing || t.finished {
+		return
+	}
+
+	t.seek(t.time + delta*t.Speed)
+	if t.onUpdate != nil {
+		t.onUpdate(t.Target)
+	}
+}
+
+func (s *tweenStep) length() float64 {
+	if s.group == nil {
+		return math.Max(s.duration, 0)
+	}
+
+	var longest float64
+	for _, child := range s.group {
+		longest = math.Max(longest, child.total())
+	}
+	return longest
+}
+
+func (t *Tweener) length() float64 {
+	var length float64
+	for _, step := range t.steps {
+		length += step.length()
+	}
+	return length
+}
+
+func (t *Tweener) total() float64 {
+	length := t.length()
+	switch {
+	case length == 0:
+		return 0
+	case t.repeat < 0:
+		return math.Inf(1)
+	}
+	return length * float64(t.repeat+1)
+}
+
+func (t *Tweener) local(at float64, iteration int, length float64) float64 {
+	position := at - float64(iteration)*length
+	if t.yoyo && iteration%2 == 1 {
+		return length - position
+	}
+	return position
+}
+
+func (t *Tweener) seek(target float64) {
+	length, total := t.length(), t.total()
+	target = math.Max(0, math.Min(target, total))
+
+	if length == 0 {
+		if !t.finished {
+			t.move(0, 0)
+			t.complete()
+		}
+		return
+	}
+
+	for t.time < target {
+		iteration := int(t.time / length)
+		end := math.Min(target, float64(iteration+1)*length)
+		t.move(t.local(t.time, iteration, length), t.local(end, iteration, length))
+		t.time = end
+
+		if end == float64(iteration+1)*length && end < total {
+			if !t.yoyo {
+				t.move(length, 0)
+			}
+			if !t.yoyo || iteration%2 == 1 {
+				for _, step := range t.steps {
+					step.fired = false
+				}
+			}
+			if t.onRepeat != nil {
+				t.onRepeat(t.Target)
+			}
+		}
+	}
+
+	for t.time > target {
+		iteration := int(math.Ceil(t.time/length)) - 1
+		start := math.Max(target, float64(iteration)*length)
+		t.move(t.local(t.time, iteration, length), t.local(start, iteration, length))
+		t.time = start
+	}
+
+	if t.time >= total {
+		t.complete()
+	}
+}
+
+func (t *Tweener) complete() {
+	if t.finished {
+		return
+	}
+	t.finished = true
+	t.playing = false
+
+	if t.onComplete != nil {
+		t.onComplete(t.Target)
+	}
+}
+
+func (t *Tweener) move(from, to float64) {
+	if from <= to {
+		var start float64
+		for _, step := range t.steps {
+			length := step.length()
+			end := start + length
+			if start > to {
+				break
+			}
+			if end >= from {
+				t.apply(step, start, length, to, true)
+			}
+			start = end
+		}
+		return
+	}
+
+	start := t.length()
+	for i := len(t.steps) - 1; i >= 0; i-- {
+		step := t.steps[i]
+		length := step.length()
+		start -= length
+		if start+length < to {
+			break
+		}
+		if start <= from {
+			t.apply(step, start, length, to, false)
+		}
+	}
+}
+
+func (t *Tweener) apply(step *tweenStep, start, length, at float64, forward bool) {
+	if step.call != nil {
+		if forward && !step.fired {
+			step.fired = true
+			step.call()
+		}
+		return
+	}
+
+	if step.group != nil {
+		for _, child := range step.group {
+			child.seek(at - start)
+		}
+		return
+	}
+
+	if !step.captured {
+		if !forward {
+			return
+		}
+		step.captured = true
+		step.channels = t.channels(step)
+		for _, channel := range step.channels {
+			channel.from = channel.get()
+		}
+	}
+
+	progress := 1.0
+	if length > 0 {
+		progress = math.Max(0, math.Min((at-start)/length, 1))
+	} else if at < start {
+		progress = 0
+	}
+	if step.easing != nil {
+		progress = step.easing(progress)
+	}
+
+	for _, channel := range step.channels {
+		values := make([]float64, len(channel.from))
+		for i := range values {
+			values[i] = channel.from[i] + (channel.to[i]-channel.from[i])*progress
+		}
+		channel.set(values)
+	}
+}
+
+func (t *Tweener) channels(step *tweenStep) []*tweenChannel {
+	var channels []*tweenChannel
+
+	for value, to := range step.floats {
+		channels = append(channels, &tweenChannel{
+			get: func() []float64 { return []float64{*value} },
+			set: func(v []float64) { *value = v[0] },
+			to:  []float64{to},
+		})
+	}
+
+	s := t.Target
+	if s == nil {
+		return channels
+	}
+
+	for key, value := range step.props {
+		if key == "Background" {
+			if c, ok := value.(color.Color); ok {
+				channels = append(channels, s.backgroundChannel(c))
+			}
+			continue
+		}
+
+		to, ok := tweenFloat(value)
+		if !ok {
+			continue
+		}
+		if channel := s.tweenChannel(key, to); channel != nil {
+			channels = append(channels, channel)
+		}
+	}
+	return channels
+}
+
+func tweenFloat(value interface{}) (float64, bool) {
+	switch v := value.(type) {
+	case float64:
+		return v, true
+	case float32:
+		return float64(v), true
+	case int:
+		return float64(v), true
+	case int64:
+		return float64(v), true
+	}
+	return 0, false
+}
+
+func (s *Shape) tweenChannel(key string, to float64) *tweenChannel {
+	channel := &tweenChannel{to: []float64{to}}
+
+	switch key {
+	case "X":
+		channel.get = func() []float64 { return []float64{s.X} }
+		channel.set = func(v []float64) { s.tweenPosition(v[0], s.Y, true, false) }
+	case "Y":
+		channel.get = func() []float64 { return []float64{s.Y} }
+		channel.set = func(v []float64) { s.tweenPosition(s.X, v[0], false, true) }
+	case "RotationAngle":
+		channel.get = func() []float64 { return []float64{s.RotationAngle} }
+		channel.set = func(v []float64) {
+			s.RotationAngle = v[0]
+			if s.Body != nil {
+				s.Body.SetTransform(s.Body.GetPosition(), v[0])
+				s.Body.SetAngularVelocity(0)
+			}
+		}
+	default:
+		info, ok := reflect.TypeOf(s).Elem().FieldByName(key)
+		if !ok || len(info.Index) != 1 || !info.IsExported() || info.Type.Kind() != reflect.Float64 {
+			return nil
+		}
+		field := reflect.ValueOf(s).Elem().Field(info.Index[0])
+		channel.get = func() []float64 { return []float64{field.Float()} }
+		channel.set = func(v []float64) { field.SetFloat(v[0]) }
+	}
+	return channel
+}
+
+func (s *Shape) tweenPosition(x, y float64, stopX, stopY bool) {
+	if s.Body == nil {
+		s.X, s.Y = x, y
+		return
+	}
+
+	s.SetPosition(x, y)
+	if s.Body.GetType() == box2d.B2BodyType.B2_dynamicBody {
+		velocity := s.Body.GetLinearVelocity()
+		if stopX {
+			velocity.X = 0
+		}
+		if stopY {
+			velocity.Y = 0
+		}
+		s.Body.SetLinearVelocity(velocity)
+	}
+}
+
+func (s *Shape) backgroundChannel(to color.Color) *tweenChannel {
+	return &tweenChannel{
+		get: func() []float64 { return colorChannels(s.Background) },
+		set: func(v []float64) {
+			// Overshooting easings leave the valid range, so clamp to keep the
+			// color premultiplied instead of letting the conversion wrap.
+			a := math.Max(0, math.Min(v[3], 0xffff))
+			channel := func(c float64) uint16 { return uint16(math.Max(0, math.Min(c, a))) }
+			s.Background = color.RGBA64{R: channel(v[0]), G: channel(v[1]), B: channel(v[2]), A: uint16(a)}
+		},
+		to: colorChannels(to),
+	}
+}
+
+func colorChannels(c color.Color) []float64 {
+	if c == nil {
+		return []float64{0, 0, 0, 0}
+	}
+	r, g, b, a := c.RGBA()
+	return []float64{float64(r), float64(g), float64(b), float64(a)}
+}
+
+func (s *Shape) StopTweens() {
+	for _, t := range s.tweens {
+		t.Stop()
+	}
+}
+
+func (s *Shape) updateTweens(delta float64) {
+	updateTweenList(&s.tweens, delta)
+}
+
+func updateTweenList(list *[]*Tweener, delta float64) {
+	if len(*list) == 0 {
+		return
+	}
+
+	tweens := *list
+	*list = nil
+	for _, t := range tweens {
+		if !t.owned {
+			t.Update(delta)
+		}
+	}
+
+	active := tweens[:0]
+	for _, t := range tweens {
+		if t.playing && !t.finished && !t.owned {
+			active = append(active, t)
+		} else {
+			t.registered = false
+		}
+	}
+	*list = append(active, *list...)
+}
@@ -0,0 +1,207 @@
+package life
+
+import (
+	"unicode"
+)
+
+type EventTypewriterCharData struct {
+	Rune  rune
+	Index int
+}
+
+type TypewriterProps struct {
+	Speed      float64
+	Paused     bool
+	Markup     bool
+	OnChar     func(r rune, index int)
+	OnFinished func()
+}
+
+type Typewriter struct {
+	*EventEmitter
+
+	Text       string
+	Speed      float64
+	Paused     bool
+	Markup     bool
+	OnChar     func(r rune, index int)
+	OnFinished func()
+
+	runes    []rune
+	total    int
+	progress float64
+	revealed int
+	cursor   int
+	finished bool
+	world    *World
+}
+
+func NewTypewriter(text string, props *TypewriterProps) *Typewriter {
+	if props == nil {
+		props = &TypewriterProps{}
+	}
+
+	if props.Speed == 0 {
+		props.Speed = 30
+	}
+
+	t := &Typewriter{
+		EventEmitter: NewEventEmitter(),
+		Speed:        props.Speed,
+		Paused:       props.Paused,
+		Markup:       props.Markup,
+		OnChar:       props.OnChar,
+		OnFinished:   props.OnFinished,
+	}
+	t.Reset(text)
+	return t
+}
+
+func (t *Typewriter) Reset(text string) {
+	t.Text = text
+	if t.Markup {
+		text = StripMarkup(text)
+	}
+	t.runes = []rune(text)
+	t.total = countGlyphs(text)
+	t.progress = 0
+	t.revealed = 0
+	t.cursor = 0
+	t.finished = t.total == 0
+
+	if t.world != nil {
+		t.world.addTypewriter(t)
+	}
+}
+
+func (t *Typewriter) Update(delta float64) {
+	if t.finished || t.Paused || t.Speed <= 0 {
+		return
+	}
+
+	t.progress += delta * t.Speed
+	for t.revealed < t.total && float64(t.revealed) < t.progress {
+		t.advance(true)
+	}
+
+	if t.revealed >= t.total {
+		t.finish()
+	}
+}
+
+func (t *Typewriter) advance(notify bool) {
+	for t.cursor < len(t.runes) {
+		r := t.runes[t.cursor]
+		t.cursor++
+		if unicode.IsSpace(r) {
+			continue
+		}
+
+		index := t.revealed
+		t.revealed++
+		if notify {
+			if t.OnChar != nil {
+				t.OnChar(r, index)
+			}
+			t.Emit(EventTypewriterChar, EventTypewriterCharData{Rune: r, Index: index})
+		}
+		return
+	}
+}
+
+func (t *Typewriter) finish() {
+	if t.finished {
+		return
+	}
+	t.finished = true
+	t.progress = float64(t.total)
+
+	if t.OnFinished != nil {
+		t.OnFinished()
+	}
+	t.Emit(EventTypewriterDone, nil)
+}
+
+func (t *Typewriter) Skip() {
+	for t.revealed < t.total {
+		t.advance(false)
+	}
+	t.finish()
+}
+
+func (t *Typewriter) Visible() int {
+	if t.finished {
+		return t.total
+	}
+	return t.revealed
+}
+
+func (t *Typewriter) Finished() bool {
+	return t.finished
+}
+
+func countGlyphs(text string) int {
+	count := 0
+	for _, r := range text {
+		if !unicode.IsSpace(r) {
+			count++
+		}
+	}
+	return count
+}
+
+func (w *World) NewTypewriter(text string, props *TypewriterProps) *Typewriter {
+	t := NewTypewriter(text, props)
+	t.world = w
+	w.addTypewriter(t)
+	return t
+}
+
+func (w *World) addTypewriter(t *Typewriter) {
+	w.typewriterMutex.Lock()
+	defer w.typewriterMutex.Unlock()
+
+	for _, existing := range w.typewriters {
+		if existing == t {
+			return
+		}
+	}
+	w.typewriters = append(w.typewriters, t)
+}
+
+func (w *World) RemoveTypewriter(t *Typewriter) {
+	w.typewriterMutex.Lock()
+	defer w.typewriterMutex.Unlock()
+
+	for i, existing := range w.typewriters {
+		if existing == t {
+			w.typewriters = append(w.typewriters[:i], w.typewriters[i+1:]...)
+			break
+		}
+	}
+	t.world = nil
+}
+
+func (w *World) updateTypewriters(delta float64) {
+	w.typewriterMutex.Lock()
+	typewriters := append(w.typewriterList[:0], w.typewriters...)
+	w.typewriterList = typewriters
+	w.typewriterMutex.Unlock()
+
+	for _, t := range typewriters {
+		t.Update(delta)
+	}
+
+	w.typewriterMutex.Lock()
+	active := w.typewriters[:0]
+	for _, t := range w.typewriters {
+		if !t.finished {
+			active = append(active, t)
+		}
+	}
+	for i := len(active); i < len(w.typewriters); i++ {
+		w.typewriters[i] = nil
+	}
+	w.typewriters = active
+	w.typewriterMutex.Unlock()
+}
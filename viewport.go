@@ -0,0 +1,167 @@
+package life
+
+import (
+	"math"
+
+	"github.com/hajimehoshi/ebiten/v2"
+)
+
+type ScaleMode string
+
+const (
+	ScaleLetterbox    ScaleMode = "letterbox"
+	ScalePixelPerfect ScaleMode = "pixel-perfect"
+	ScaleExpand       ScaleMode = "expand"
+	ScaleStretch      ScaleMode = "stretch"
+)
+
+type EventResizeData struct {
+	WindowWidth  int
+	WindowHeight int
+	ViewWidth    int
+	ViewHeight   int
+	Scale        Vector2
+	Offset       Vector2
+}
+
+type viewport struct {
+	windowWidth  int
+	windowHeight int
+	viewWidth    int
+	viewHeight   int
+	scale        Vector2
+	offset       Vector2
+	canvas       *ebiten.Image
+}
+
+func (w *World) updateViewport(windowWidth, windowHeight int) {
+	if windowWidth <= 0 || windowHeight <= 0 {
+		windowWidth, windowHeight = w.Width, w.Height
+	}
+
+	baseWidth, baseHeight := float64(w.Width), float64(w.Height)
+	outerWidth, outerHeight := float64(windowWidth), float64(windowHeight)
+	fit := math.Min(outerWidth/baseWidth, outerHeight/baseHeight)
+
+	next := viewport{
+		windowWidth:  windowWidth,
+		windowHeight: windowHeight,
+		viewWidth:    w.Width,
+		viewHeight:   w.Height,
+		scale:        Vector2{X: fit, Y: fit},
+		canvas:       w.viewport.canvas,
+	}
+
+	switch w.ScaleMode {
+	case ScaleStretch:
+		next.scale = Vector2{X: outerWidth / baseWidth, Y: outerHeight / baseHeight}
+	case ScaleExpand:
+		next.viewWidth = int(math.Ceil(outerWidth / fit))
+		next.viewHeight = int(math.Ceil(outerHeight / fit))
+	case ScalePixelPerfect:
+		if fit >= 1 {
+			fit = math.Floor(fit)
+		}
+		next.scale = Vector2{X: fit, Y: fit}
+		next.offset = Vector2{
+			X: math.Floor((outerWidth - baseWidth*fit) / 2),
+			Y: math.Floor((outerHeight - baseHeight*fit) / 2),
+		}
+	default:
+		next.offset = Vector2{
+			X: (outerWidth - baseWidth*fit) / 2,
+			Y: (outerHeight - baseHeight*fit) / 2,
+		}
+	}
+
+	previous := w.viewport
+	w.viewport = next
+	if previous.windowWidth == next.windowWidth && previous.windowHeight == next.windowHeight &&
+		previous.viewWidth == next.viewWidth && previous.viewHeight == next.viewHeight &&
+		previous.scale == next.scale && previous.offset == next.offset {
+		return
+	}
+
+	w.Emit(EventResize, EventResizeData{
+		WindowWidth:  next.windowWidth,
+		WindowHeight: next.windowHeight,
+		ViewWidth:    next.viewWidth,
+		ViewHeight:   next.viewHeight,
+		Scale:        next.scale,
+		Offset:       next.offset,
+	})
+}
+
+func (w *World) SetScaleMode(mode ScaleMode) {
+	w.ScaleMode = mode
+	if w.viewport.windowWidth > 0 {
+		w.updateViewport(w.viewport.windowWidth, w.viewport.windowHeight)
+	}
+}
+
+func (w *World) ViewSize() (int, int) {
+	if w.viewport.viewWidth == 0 || w.viewport.viewHeight == 0 {
+		return w.Width, w.Height
+	}
+	return w.viewport.viewWidth, w.viewport.viewHeight
+}
+
+func (w *World) WindowToView(x, y float64) Vector2 {
+	v := &w.viewport
+	if v.scale.X == 0 || v.scale.Y == 0 {
+		return Vector2{X: x, Y: y}
+	}
+	return Vector2{X: (x - v.offset.X) / v.scale.X, Y: (y - v.offset.Y) / v.scale.Y}
+}
+
+func (w *World) ViewToWindow(x, y float64) Vector2 {
+	v := &w.viewport
+	if v.scale.X == 0 || v.scale.Y == 0 {
+		return Vector2{X: x, Y: y}
+	}
+	return Vector2{X: x*v.scale.X + v.offset.X, Y: y*v.scale.Y + v.offset.Y}
+}
+
+func (v *viewport) direct() bool {
+	return v.scale.X == 1 && v.scale.Y == 1 && v.offset == (Vector2{}) &&
+		v.viewWidth == v.windowWidth && v.viewHeight == v.windowHeight
+}
+
+func (w *World) viewCanvas(screen *ebiten.Image) *ebiten.Image {
+	v := &w.viewport
+	if v.viewWidth == 0 || v.direct() {
+		return screen
+	}
+
+	if v.canvas == nil || v.canvas.Bounds().Dx() != v.viewWidth || v.canvas.Bounds().Dy() != v.viewHeight {
+		if v.canvas != nil {
+			v.canvas.Deallocate()
+		}
+		v.canvas = ebiten.NewImage(v.viewWidth, v.viewHeight)
+	}
+	v.canvas.Clear()
+	return v.canvas
+}
+
+func (w *World) presentView(screen, canvas *ebiten.Image) {
+	if canvas == screen {
+		return
+	}
+
+	v := &w.viewport
+	if w.LetterboxColor != nil {
+		screen.Fill(w.LetterboxColor)
+		countDrawCall()
+	}
+
+	op := &ebiten.DrawImageOptions{}
+	op.GeoM.Scale(v.scale.X, v.scale.Y)
+	op.GeoM.Translate(v.offset.X, v.offset.Y)
+	if w.ScaleMode == ScalePixelPerfect {
+		op.Filter = ebiten.FilterNearest
+	} else {
+		op.Filter = ebiten.FilterLinear
+	}
+	screen.DrawImage(canvas, op)
+	countDrawCall()
+}
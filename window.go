@@ -0,0 +1,332 @@
+package life
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"image"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/hajimehoshi/ebiten/v2"
+)
+
+type WindowSettings struct {
+	Width       int  `json:"width"`
+	Height      int  `json:"height"`
+	X           int  `json:"x"`
+	Y           int  `json:"y"`
+	HasPosition bool `json:"has_position"`
+	Fullscreen  bool `json:"fullscreen"`
+	Borderless  bool `json:"borderless"`
+	Resizable   bool `json:"resizable"`
+	MinWidth    int  `json:"min_width"`
+	MinHeight   int  `json:"min_height"`
+	MaxWidth    int  `json:"max_width"`
+	MaxHeight   int  `json:"max_height"`
+	VSync       bool `json:"vsync"`
+	TPS         int  `json:"tps"`
+	MaxFPS      int  `json:"max_fps"`
+
+	AutoPause   bool          `json:"-"`
+	IgnoreDPI   bool          `json:"-"`
+	Persist     bool          `json:"-"`
+	PersistPath string        `json:"-"`
+	Icons       []image.Image `json:"-"`
+}
+
+type EventFocusData struct {
+	Focused bool
+}
+
+type EventWindowSettingsData struct {
+	Path string
+	Err  error
+}
+
+type windowState struct {
+	focused    bool
+	autoPaused bool
+	lastDraw   time.Time
+	dirty      bool
+	changedAt  time.Time
+}
+
+func DefaultWindowSettings(width, height int) *WindowSettings {
+	return &WindowSettings{
+		Width:     width,
+		Height:    height,
+		Resizable: true,
+		VSync:     true,
+		TPS:       ebiten.DefaultTPS,
+	}
+}
+
+func (w *World) windowSettingsPath() string {
+	if w.Window.PersistPath != "" {
+		return w.Window.PersistPath
+	}
+
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		dir = "."
+	}
+	name := strings.Map(func(r rune) rune {
+		if strings.ContainsRune(`<>:"/\|?* `, r) {
+			return '-'
+		}
+		return r
+	}, w.Title)
+	return filepath.Join(dir, name, "window.json")
+}
+
+func (w *World) LoadWindowSettings() error {
+	path := w.windowSettingsPath()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read window settings %s: %w", path, err)
+	}
+
+	saved := *w.Window
+	if err := json.Unmarshal(data, &saved); err != nil {
+		return fmt.Errorf("failed to parse window settings %s: %w", path, err)
+	}
+	*w.Window = saved
+	return nil
+}
+
+func (w *World) SaveWindowSettings() error {
+	path := w.windowSettingsPath()
+	data, err := json.MarshalIndent(w.Window, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode window settings: %w", err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("failed to create %s: %w", filepath.Dir(path), err)
+	}
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		return fmt.Errorf("failed to write window settings %s: %w", path, err)
+	}
+	w.windowState.dirty = false
+	return nil
+}
+
+func (w *World) loadPersistedWindowSettings() {
+	if err := w.LoadWindowSettings(); err != nil && !errors.Is(err, os.ErrNotExist) {
+		w.reportWindowSettings(err)
+	}
+}
+
+func (w *World) savePersistedWindowSettings() {
+	if err := w.SaveWindowSettings(); err != nil {
+		// Wait for the next change instead of retrying a failing write every frame.
+		w.windowState.dirty = false
+		w.reportWindowSettings(err)
+	}
+}
+
+func (w *World) reportWindowSettings(err error) {
+	w.Emit(EventWindowSettings, EventWindowSettingsData{Path: w.windowSettingsPath(), Err: err})
+}
+
+func (w *World) applyWindowSettings() {
+	settings := w.Window
+
+	ebiten.SetWindowTitle(w.Title)
+	ebiten.SetWindowSize(settings.Width, settings.Height)
+	ebiten.SetWindowDecorated(!settings.Borderless)
+	ebiten.SetWindowSizeLimits(limit(settings.MinWidth), limit(settings.MinHeight), limit(settings.MaxWidth), limit(settings.MaxHeight))
+	ebiten.SetFullscreen(settings.Fullscreen)
+	ebiten.SetVsyncEnabled(settings.VSync)
+	ebiten.SetScreenClearedEveryFrame(settings.MaxFPS <= 0)
+
+	if settings.TPS != 0 {
+		ebiten.SetTPS(settings.TPS)
+	}
+	if settings.HasPosition {
+		ebiten.SetWindowPosition(settings.X, settings.Y)
+	}
+	if settings.Resizable {
+		ebiten.SetWindowResizingMode(ebiten.WindowResizingModeEnabled)
+	} else {
+		ebiten.SetWindowResizingMode(ebiten.WindowResizingModeDisabled)
+	}
+	if len(settings.Icons) > 0 {
+		ebiten.SetWindowIcon(settings.Icons)
+	}
+}
+
+func limit(size int) int {
+	if size <= 0 {
+		return -1
+	}
+	return size
+}
+
+func (w *World) SetFullscreen(fullscreen bool) {
+	w.Window.Fullscreen = fullscreen
+	ebiten.SetFullscreen(fullscreen)
+	w.markWindowDirty()
+}
+
+func (w *World) ToggleFullscreen() {
+	w.SetFullscreen(!ebiten.IsFullscreen())
+}
+
+func (w *World) IsFullscreen() bool {
+	return ebiten.IsFullscreen()
+}
+
+func (w *World) SetBorderless(borderless bool) {
+	w.Window.Borderless = borderless
+	ebiten.SetWindowDecorated(!borderless)
+	w.markWindowDirty()
+}
+
+func (w *World) SetResizable(resizable bool) {
+	w.Window.Resizable = resizable
+	if resizable {
+		ebiten.SetWindowResizingMode(ebiten.WindowResizingModeEnabled)
+	} else {
+		ebiten.SetWindowResizingMode(ebiten.WindowResizingModeDisabled)
+	}
+	w.markWindowDirty()
+}
+
+func (w *World) SetWindowSize(width, height int) {
+	w.Window.Width, w.Window.Height = width, height
+	ebiten.SetWindowSize(width, height)
+	w.markWindowDirty()
+}
+
+func (w *World) WindowSize() (int, int) {
+	return ebiten.WindowSize()
+}
+
+func (w *World) SetWindowSizeLimits(minWidth, minHeight, maxWidth, maxHeight int) {
+	w.Window.MinWidth, w.Window.MinHeight = minWidth, minHeight
+	w.Window.MaxWidth, w.Window.MaxHeight = maxWidth, maxHeight
+	ebiten.SetWindowSizeLimits(limit(minWidth), limit(minHeight), limit(maxWidth), limit(maxHeight))
+	w.markWindowDirty()
+}
+
+func (w *World) SetWindowPosition(x, y int) {
+	w.Window.X, w.Window.Y, w.Window.HasPosition = x, y, true
+	ebiten.SetWindowPosition(x, y)
+	w.markWindowDirty()
+}
+
+func (w *World) WindowPosition() (int, int) {
+	return ebiten.WindowPosition()
+}
+
+func (w *World) SetVSync(enabled bool) {
+	w.Window.VSync = enabled
+	ebiten.SetVsyncEnabled(enabled)
+	w.markWindowDirty()
+}
+
+func (w *World) SetTPS(tps int) {
+	w.Window.TPS = tps
+	ebiten.SetTPS(tps)
+	w.markWindowDirty()
+}
+
+func (w *World) SetMaxFPS(fps int) {
+	w.Window.MaxFPS = fps
+	ebiten.SetScreenClearedEveryFrame(fps <= 0)
+	w.markWindowDirty()
+}
+
+func (w *World) SetWindowIcon(icons ...image.Image) {
+	w.Window.Icons = icons
+	ebiten.SetWindowIcon(icons)
+}
+
+func (w *World) SetAutoPause(enabled bool) {
+	w.Window.AutoPause = enabled
+}
+
+func (w *World) IsFocused() bool {
+	return ebiten.IsFocused()
+}
+
+func (w *World) DeviceScaleFactor() float64 {
+	if w.Window.IgnoreDPI {
+		return 1
+	}
+	if monitor := ebiten.Monitor(); monitor != nil {
+		if scale := monitor.DeviceScaleFactor(); scale > 0 {
+			return scale
+		}
+	}
+	return 1
+}
+
+func (w *World) markWindowDirty() {
+	if w.Window.Persist {
+		w.windowState.dirty = true
+		w.windowState.changedAt = time.Now()
+	}
+}
+
+func (w *World) updateWindow() {
+	focused := ebiten.IsFocused()
+	if focused != w.windowState.focused {
+		w.windowState.focused = focused
+		if focused {
+			if w.windowState.autoPaused {
+				w.windowState.autoPaused = false
+				w.Resume()
+			}
+			w.Emit(EventFocusGained, EventFocusData{Focused: true})
+		} else {
+			if w.Window.AutoPause && !w.Paused {
+				w.windowState.autoPaused = true
+				w.Pause()
+			}
+			w.Emit(EventFocusLost, EventFocusData{Focused: false})
+		}
+	}
+
+	if !w.Window.Persist {
+		return
+	}
+
+	settings := w.Window
+	if fullscreen := ebiten.IsFullscreen(); fullscreen != settings.Fullscreen {
+		settings.Fullscreen = fullscreen
+		w.markWindowDirty()
+	}
+	if !settings.Fullscreen && !ebiten.IsWindowMinimized() && !ebiten.IsWindowMaximized() {
+		if width, height := ebiten.WindowSize(); width > 0 && height > 0 && (width != settings.Width || height != settings.Height) {
+			settings.Width, settings.Height = width, height
+			w.markWindowDirty()
+		}
+		if x, y := ebiten.WindowPosition(); !settings.HasPosition || x != settings.X || y != settings.Y {
+			settings.X, settings.Y, settings.HasPosition = x, y, true
+			w.markWindowDirty()
+		}
+	}
+
+	if w.windowState.dirty && time.Since(w.windowState.changedAt) > 500*time.Millisecond {
+		w.savePersistedWindowSettings()
+	}
+}
+
+func (w *World) skipFrame() bool {
+	if w.Window.MaxFPS <= 0 {
+		return false
+	}
+
+	now := time.Now()
+	interval := time.Second / time.Duration(w.Window.MaxFPS)
+	if !w.windowState.lastDraw.IsZero() && now.Sub(w.windowState.lastDraw) < interval-time.Millisecond {
+		return true
+	}
+	w.windowState.lastDraw = now
+	return false
+}
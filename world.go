@@ -4,7 +4,6 @@ import (
 	"embed"
 	"image/color"
 	"math"
-	"sort"
 	"sync"
 	"time"
 
@@ -172,7 +171,11 @@ type World struct {
 	Screen *ebiten.Image
 
 	drawCommands []DrawCommand
+	penCommands  []DrawCommand
+	penShapes    []Shape
+	drawList     []*Shape
 	drawMutex    sync.Mutex
+	batch        Batch
 
 	Tick       GameLoop
 	Init       func()
@@ -182,17 +185,68 @@ type World struct {
 
 	Pattern    PatternType
 	Background color.Color
+	Image      *ebiten.Image
+	Gradient   *Gradient
+	Tile       *TilePattern
+	NineSlice  *NineSlice
 	Border     *Border
 
+	backgroundShape Shape
+	backgroundProps ShapeProps
+
+	Camera         Vector2
+	ParallaxLayers []*ParallaxLayer
+	parallaxMutex  sync.RWMutex
+
+	Emitters        []*ParticleEmitter
+	emitterMutex    sync.RWMutex
+	emitterDrawList []*ParticleEmitter
+
+	typewriters     []*Typewriter
+	typewriterList  []*Typewriter
+	typewriterMutex sync.Mutex
+
+	tweens []*Tweener
+
+	TimeScale        float64
+	gameTime         float64
+	timers           []*Timer
+	timerList        []*Timer
+	coroutines       []*Coroutine
+	coroutineList    []*Coroutine
+	runningCoroutine *Coroutine
+	eventWaiters     map[eventWaitKey][]*eventWaiter
+	schedulerMutex   sync.Mutex
+
+	Lights          []*Light
+	AmbientColor    color.Color
+	AmbientDarkness float64
+	LightingEnabled bool
+	lightMutex      sync.RWMutex
+	lightmap        *ebiten.Image
+	lightBuffer     *ebiten.Image
+	lightBatch      Batch
+	lightTextures   lightTextureCache
+
+	pendingScreenshots []string
+	recorders          []*Recorder
+	captureMutex       sync.Mutex
+
 	Objects []*Shape
 	mutex   sync.RWMutex
 
+	layers       []*Layer
+	layerMutex   sync.RWMutex
+	drawOrderSeq int64
+	drawBackSeq  int64
+
 	AudioManager *AudioManager
 
 	Mouse struct {
 		X, Y                          float64
 		IsLeftClicked, IsRightClicked bool
 		IsMiddleClicked               bool
+		DeltaX, DeltaY                float64
 	}
 	Keys      map[ebiten.Key]bool
 	keysMutex sync.RWMutex
@@ -201,6 +255,20 @@ type World struct {
 	Paused    bool
 	Cursor    CursorType
 
+	cursorState cursorState
+
+	DebugHUD bool
+	DebugKey ebiten.Key
+	perf     perfState
+	Console  *Console
+
+	ScaleMode      ScaleMode
+	LetterboxColor color.Color
+	viewport       viewport
+
+	Window      *WindowSettings
+	windowState windowState
+
 	OnMouseDown func(x, y float64)
 	OnMouseUp   func(x, y float64)
 	OnMouseMove func(x, y float64)
@@ -209,6 +277,9 @@ type World struct {
 	CurrentLevel int
 
 	pendingLevelSwitch *int
+	levelMounted       bool
+	transition         *levelTransition
+	transitionSnapshot *ebiten.Image
 	collisionQueue     []CollisionEvent
 	collisionMutex     sync.Mutex
 }
@@ -320,6 +391,10 @@ type WorldProps struct {
 	G             Vector2
 	Pattern       PatternType
 	Background    color.Color
+	Image         *ebiten.Image
+	Gradient      *Gradient
+	Tile          *TilePattern
+	NineSlice     *NineSlice
 	HasLimits     bool
 	Border        *Border
 	Paused        bool
@@ -327,6 +402,23 @@ type WorldProps struct {
 	Title         string
 	AirResistance float64
 	AudioProps    *AudioProps
+	TimeScale     float64
+
+	ScaleMode      ScaleMode
+	LetterboxColor color.Color
+	Window         *WindowSettings
+	DebugHUD       bool
+	Console        bool
+
+	// DebugKey defaults to F3 and ConsoleKey to the grave accent; KeyNone
+	// unbinds them. ebiten.KeyA is the zero value, so bind it through
+	// World.DebugKey or EnableConsole instead.
+	DebugKey   ebiten.Key
+	ConsoleKey ebiten.Key
+
+	AmbientColor    color.Color
+	AmbientDarkness float64
+	LightingEnabled bool
 
 	Levels       []Level
 	CurrentLevel int
@@ -355,6 +447,27 @@ func NewWorld(props *WorldProps) *World {
 	if props.Title == "" {
 		props.Title = "Life Game"
 	}
+	if props.TimeScale == 0 {
+		props.TimeScale = 1
+	}
+	if props.ScaleMode == "" {
+		props.ScaleMode = ScaleLetterbox
+	}
+	if props.LetterboxColor == nil {
+		props.LetterboxColor = color.RGBA{0, 0, 0, 255}
+	}
+	if props.DebugKey == 0 {
+		props.DebugKey = ebiten.KeyF3
+	}
+	if props.ConsoleKey == 0 {
+		props.ConsoleKey = ebiten.KeyGraveAccent
+	}
+	if props.Window == nil {
+		props.Window = DefaultWindowSettings(props.Width, props.Height)
+	}
+	if props.Window.Width == 0 || props.Window.Height == 0 {
+		props.Window.Width, props.Window.Height = props.Width, props.Height
+	}
 
 	contactListener := ContactListener{}
 
@@ -375,19 +488,38 @@ func NewWorld(props *WorldProps) *World {
 		Tick:               nil,
 		Pattern:            props.Pattern,
 		Background:         props.Background,
+		Image:              props.Image,
+		Gradient:           props.Gradient,
+		Tile:               props.Tile,
+		NineSlice:          props.NineSlice,
 		Border:             props.Border,
+		AmbientColor:       props.AmbientColor,
+		AmbientDarkness:    props.AmbientDarkness,
+		LightingEnabled:    props.LightingEnabled,
 		Paused:             props.Paused,
 		Cursor:             props.Cursor,
 		Keys:               make(map[ebiten.Key]bool),
 		lastUpdate:         time.Now(),
 		Title:              props.Title,
 		AirResistance:      props.AirResistance,
+		TimeScale:          props.TimeScale,
+		ScaleMode:          props.ScaleMode,
+		LetterboxColor:     props.LetterboxColor,
+		Window:             props.Window,
+		DebugHUD:           props.DebugHUD,
+		DebugKey:           props.DebugKey,
+		windowState:        windowState{focused: true},
 		AudioManager:       NewAudioManager(props.AudioProps),
 		Levels:             props.Levels,
 		CurrentLevel:       0,
 		pendingLevelSwitch: nil,
 		collisionQueue:     make([]CollisionEvent, 0),
 		drawCommands:       make([]DrawCommand, 0),
+		layers:             defaultLayers(),
+	}
+
+	if props.Console {
+		world.EnableConsole(props.ConsoleKey)
 	}
 
 	if len(world.Levels) == 0 {
@@ -450,13 +582,22 @@ func (w *World) Pen(shapeType ShapeType, props *ShapeProps) {
 	if drawProps.Background == nil {
 		drawProps.Background = color.RGBA{255, 255, 255, 255}
 	}
+	if shapeType == ShapeText && (drawProps.Width == 0 || drawProps.Height == 0) {
+		width, height := measureShapeText(&drawProps)
+		if drawProps.Width == 0 {
+			drawProps.Width = width
+		}
+		if drawProps.Height == 0 {
+			drawProps.Height = height
+		}
+	}
 	if drawProps.Width == 0 {
 		drawProps.Width = 10
 	}
 	if drawProps.Height == 0 {
 		drawProps.Height = 10
 	}
-	if drawProps.Radius == 0 && shapeType == ShapeCircle {
+	if drawProps.Radius == 0 && (shapeType == ShapeCircle || shapeType == ShapeArc) {
 		drawProps.Radius = 10
 	}
 
@@ -514,6 +655,88 @@ func (w *World) Rect(x, y, width, height float64, rectColor color.Color) {
 	})
 }
 
+func (w *World) Ellipse(x, y, radiusX, radiusY float64, ellipseColor color.Color) {
+	w.Pen(ShapeEllipse, &ShapeProps{
+		X:          x - radiusX,
+		Y:          y - radiusY,
+		Width:      radiusX * 2,
+		Height:     radiusY * 2,
+		Background: ellipseColor,
+		Pattern:    PatternColor,
+		ZIndex:     1000,
+	})
+}
+
+func (w *World) RoundedRect(x, y, width, height, radius float64, rectColor color.Color) {
+	w.Pen(ShapeRectangle, &ShapeProps{
+		X:            x,
+		Y:            y,
+		Width:        width,
+		Height:       height,
+		CornerRadius: radius,
+		Background:   rectColor,
+		Pattern:      PatternColor,
+		ZIndex:       1000,
+	})
+}
+
+func (w *World) StrokeLine(x1, y1, x2, y2 float64, stroke *Stroke) {
+	if stroke == nil || stroke.Width <= 0 {
+		return
+	}
+
+	dx := x2 - x1
+	dy := y2 - y1
+	length := math.Sqrt(dx*dx + dy*dy)
+
+	w.Pen(ShapeLine, &ShapeProps{
+		X:        x1 + dx/2 - length/2,
+		Y:        y1 + dy/2 - stroke.Width/2,
+		Width:    length,
+		Height:   stroke.Width,
+		Rotation: math.Atan2(dy, dx),
+		Pattern:  PatternNone,
+		Stroke:   stroke,
+		ZIndex:   1000,
+	})
+}
+
+func (w *World) StrokeRect(x, y, width, height float64, stroke *Stroke) {
+	w.Pen(ShapeRectangle, &ShapeProps{
+		X:       x,
+		Y:       y,
+		Width:   width,
+		Height:  height,
+		Pattern: PatternNone,
+		Stroke:  stroke,
+		ZIndex:  1000,
+	})
+}
+
+func (w *World) StrokeCircle(x, y, radius float64, stroke *Stroke) {
+	w.Pen(ShapeCircle, &ShapeProps{
+		X:       x - radius,
+		Y:       y - radius,
+		Radius:  radius,
+		Pattern: PatternNone,
+		Stroke:  stroke,
+		ZIndex:  1000,
+	})
+}
+
+func (w *World) Arc(x, y, radius, startAngle, endAngle float64, stroke *Stroke) {
+	w.Pen(ShapeArc, &ShapeProps{
+		X:        x - radius,
+		Y:        y - radius,
+		Radius:   radius,
+		ArcStart: startAngle,
+		ArcEnd:   endAngle,
+		Pattern:  PatternNone,
+		Stroke:   stroke,
+		ZIndex:   1000,
+	})
+}
+
 func (w *World) queueCollision(shapeA, shapeB *Shape) {
 	w.collisionMutex.Lock()
 	defer w.collisionMutex.Unlock()
@@ -554,6 +777,9 @@ func (w *World) processCollisions() {
 }
 
 func (w *World) Destroy() {
+	w.CancelScheduled()
+	w.clearEmitters()
+
 	w.mutex.Lock()
 	defer w.mutex.Unlock()
 
@@ -573,16 +799,21 @@ func (w *World) Destroy() {
 	}
 }
 
-func (w *World) NextLevel() {
+func (w *World) NextLevel(transition ...Transition) {
 	if w.CurrentLevel+1 >= len(w.Levels) {
 		return
 	}
 
-	w.SwitchToLevel(w.CurrentLevel + 1)
+	w.SwitchToLevel(w.CurrentLevel+1, transition...)
 }
 
-func (w *World) SwitchToLevel(levelIndex int) {
-	if levelIndex < 0 || levelIndex >= len(w.Levels) {
+func (w *World) SwitchToLevel(levelIndex int, transition ...Transition) {
+	if levelIndex < 0 || levelIndex >= len(w.Levels) || w.transition != nil {
+		return
+	}
+
+	if len(transition) > 0 {
+		w.startTransition(levelIndex, transition[0])
 		return
 	}
 
@@ -594,14 +825,20 @@ func (w *World) SelectLevel(index int) {
 		return
 	}
 
+	if w.levelMounted && w.CurrentLevel < len(w.Levels) {
+		if previous := w.Levels[w.CurrentLevel]; previous.OnDestroy != nil {
+			previous.OnDestroy(w)
+		}
+	}
+	if w.levelMounted {
+		w.CancelScheduled()
+		w.clearEmitters()
+	}
+
 	w.CurrentLevel = index
+	w.levelMounted = true
 	level := w.Levels[index]
 
-	if level.OnDestroy != nil {
-
-		level.OnDestroy(w)
-	}
-
 	w.mutex.Lock()
 
 	for _, obj := range w.Objects {
@@ -609,10 +846,13 @@ func (w *World) SelectLevel(index int) {
 			w.PhysicsWorld.DestroyBody(obj.Body)
 			obj.Body = nil
 		}
+		obj.world = nil
 	}
 	w.Objects = make([]*Shape, 0)
 	w.mutex.Unlock()
 
+	w.clearTargetedLights()
+
 	if level.Tick != nil {
 		w.Tick = level.Tick
 	} else {
@@ -658,6 +898,7 @@ func (w *World) CreateBorders() {
 			Background: borderColor,
 			Tag:        "border",
 			Name:       "borderTop",
+			Layer:      LayerBackground,
 			Physics:    true,
 			IsBody:     true,
 		}),
@@ -670,6 +911,7 @@ func (w *World) CreateBorders() {
 			Background: borderColor,
 			Tag:        "border",
 			Name:       "borderBottom",
+			Layer:      LayerBackground,
 			Physics:    true,
 			IsBody:     true,
 		}),
@@ -682,6 +924,7 @@ func (w *World) CreateBorders() {
 			Background: borderColor,
 			Tag:        "border",
 			Name:       "borderLeft",
+			Layer:      LayerBackground,
 			Physics:    true,
 			IsBody:     true,
 		}),
@@ -694,6 +937,7 @@ func (w *World) CreateBorders() {
 			Background: borderColor,
 			Tag:        "border",
 			Name:       "borderRight",
+			Layer:      LayerBackground,
 			Physics:    true,
 			IsBody:     true,
 		}),
@@ -709,6 +953,7 @@ func (w *World) Register(object *Shape) {
 	defer w.mutex.Unlock()
 
 	object.world = w
+	object.drawOrder = w.nextDrawOrder()
 	w.Objects = append(w.Objects, object)
 	w.createPhysicsBody(object)
 }
@@ -722,6 +967,7 @@ func (w *World) Unregister(object *Shape) {
 			if obj.Body != nil {
 				w.PhysicsWorld.DestroyBody(obj.Body)
 			}
+			obj.world = nil
 			w.Objects = append(w.Objects[:i], w.Objects[i+1:]...)
 			break
 		}
@@ -772,7 +1018,7 @@ func (w *World) createPhysicsBody(object *Shape) {
 
 	fixture := body.CreateFixture(shape, density)
 
-	if object.Ghost {
+	if object.Ghost || (object.Type == ShapeText && !object.IsBody) {
 		fixture.SetSensor(true)
 	}
 
@@ -818,28 +1064,50 @@ func (w *World) GenerateLevelFromMap(levelMap Map, objects map[string]func(posit
 }
 
 func (w *World) Update() error {
-	if w.Paused {
-		return nil
-	}
+	w.updateWindow()
+	w.updateDebugHUD()
+	w.updateConsole()
+	w.updateCursor()
+	w.updateRecorderHotkeys()
 
 	now := time.Now()
-	var deltaTime float64
+	frameTime := 1.0 / 60.0
 	if !w.lastUpdate.IsZero() {
-		deltaTime = now.Sub(w.lastUpdate).Seconds()
-	} else {
-		deltaTime = 1.0 / 60.0
+		frameTime = now.Sub(w.lastUpdate).Seconds()
 	}
 	w.lastUpdate = now
 
+	w.updateTransition(frameTime)
+	lap := w.perf.lap(PerfTransition, now)
+
+	if w.Paused {
+		return nil
+	}
+
+	deltaTime := frameTime * w.TimeScale
+	defer func() { w.perf.stats.UpdateTime = time.Since(now) }()
+
 	velocityIterations := 6
 	positionIterations := 3
 	w.PhysicsWorld.Step(deltaTime, velocityIterations, positionIterations)
+	lap = w.perf.lap(PerfPhysics, lap)
+
+	w.updateParallax(deltaTime)
+	lap = w.perf.lap(PerfParallax, lap)
+	w.updateEmitters(deltaTime)
+	lap = w.perf.lap(PerfEmitters, lap)
+	w.updateTypewriters(deltaTime)
+	lap = w.perf.lap(PerfTypewriters, lap)
 
 	if w.AudioManager != nil {
 		w.AudioManager.Update()
 	}
+	lap = w.perf.lap(PerfAudio, lap)
 
 	w.processCollisions()
+	lap = w.perf.lap(PerfCollisions, lap)
+	w.updateScheduler(deltaTime)
+	lap = w.perf.lap(PerfScheduler, lap)
 
 	if w.pendingLevelSwitch != nil {
 		levelIndex := *w.pendingLevelSwitch
@@ -855,7 +1123,11 @@ func (w *World) Update() error {
 
 	for _, obj := range objects {
 		obj.Update()
+		obj.updateAnimations(deltaTime)
+		obj.updateTweens(deltaTime)
 	}
+	updateTweenList(&w.tweens, deltaTime)
+	lap = w.perf.lap(PerfObjects, lap)
 
 	if w.Tick != nil {
 		w.Tick(LoopData{
@@ -863,23 +1135,21 @@ func (w *World) Update() error {
 			Delta: deltaTime,
 		})
 	}
+	lap = w.perf.lap(PerfTick, lap)
 
 	w.updateInput()
+	w.perf.lap(PerfInput, lap)
 	return nil
 }
 
 func (w *World) updateInput() {
-	x, y := ebiten.CursorPosition()
-	w.Mouse.X = float64(x)
-	w.Mouse.Y = float64(y)
-
 	w.Mouse.IsLeftClicked = ebiten.IsMouseButtonPressed(ebiten.MouseButtonLeft)
 	w.Mouse.IsRightClicked = ebiten.IsMouseButtonPressed(ebiten.MouseButtonRight)
 	w.Mouse.IsMiddleClicked = ebiten.IsMouseButtonPressed(ebiten.MouseButtonMiddle)
 
 	w.keysMutex.Lock()
 	for key := ebiten.Key(0); key <= ebiten.KeyMax; key++ {
-		w.Keys[key] = ebiten.IsKeyPressed(key)
+		w.Keys[key] = !w.consoleOpen() && ebiten.IsKeyPressed(key)
 	}
 	w.keysMutex.Unlock()
 
@@ -925,41 +1195,99 @@ func (w *World) Draw(screen *ebiten.Image) {
 		w.Screen = screen
 	}
 
-	screen.Fill(w.Background)
+	w.batch.Begin(screen)
+
+	start := time.Now()
+	w.drawBackground(&w.batch)
+	start = w.perf.lap(PerfBackground, start)
 
 	w.mutex.RLock()
-	objects := make([]*Shape, len(w.Objects))
-	copy(objects, w.Objects)
+	w.drawList = append(w.drawList[:0], w.Objects...)
 	w.mutex.RUnlock()
 
 	w.drawMutex.Lock()
-	drawCommands := make([]DrawCommand, len(w.drawCommands))
-	copy(drawCommands, w.drawCommands)
-	w.drawCommands = w.drawCommands[:0]
+	w.penCommands, w.drawCommands = w.drawCommands, w.penCommands[:0]
 	w.drawMutex.Unlock()
 
-	var tempShapes []*Shape
-	for _, cmd := range drawCommands {
-		tempShape := NewShape(cmd.Props)
-		tempShape.Type = cmd.Type
-		tempShapes = append(tempShapes, tempShape)
+	if cap(w.penShapes) < len(w.penCommands) {
+		w.penShapes = make([]Shape, len(w.penCommands))
 	}
+	w.penShapes = w.penShapes[:len(w.penCommands)]
 
-	allShapes := append(objects, tempShapes...)
+	for i := range w.penCommands {
+		penShape := &w.penShapes[i]
+		penShape.loadDrawCommand(&w.penCommands[i])
+		penShape.world = w
+		penShape.drawOrder = math.MaxInt32 + int64(i)
+		w.drawList = append(w.drawList, penShape)
+	}
 
-	sort.Slice(allShapes, func(i, j int) bool {
-		if allShapes[i].Tag == "border" && allShapes[j].Tag != "border" {
-			return true
+	lit := false
+	emitters := w.prepareEmitters()
+	for _, obj := range w.sortForDrawing(w.drawList) {
+		for len(emitters) > 0 && emitterBefore(emitters[0], obj) {
+			lit = w.drawEmitter(&w.batch, emitters[0], lit)
+			emitters = emitters[1:]
 		}
-		if allShapes[i].Tag != "border" && allShapes[j].Tag == "border" {
-			return false
+		if !lit && w.isScreenSpace(obj.Layer) {
+			lit = w.flushLighting(screen)
 		}
-		return allShapes[i].ZIndex < allShapes[j].ZIndex
-	})
+		obj.DrawBatched(&w.batch)
+	}
+	for _, emitter := range emitters {
+		lit = w.drawEmitter(&w.batch, emitter, lit)
+	}
+	if !lit {
+		w.flushLighting(screen)
+	}
 
-	for _, obj := range allShapes {
-		obj.Draw(screen)
+	w.batch.End()
+	w.perf.lap(PerfShapes, start)
+	w.perf.stats.Sections[PerfShapes] -= w.perf.stats.Sections[PerfLighting]
+}
+
+func (w *World) flushLighting(screen *ebiten.Image) bool {
+	w.batch.Flush()
+	start := time.Now()
+	w.applyLighting(screen)
+	w.perf.lap(PerfLighting, start)
+	return true
+}
+
+func (w *World) drawBackground(b *Batch) {
+	b.target.Fill(w.Background)
+	countDrawCall()
+
+	if w.Pattern != PatternColor {
+		width, height := w.ViewSize()
+		w.backgroundProps = ShapeProps{
+			Width:      float64(width),
+			Height:     float64(height),
+			Pattern:    w.Pattern,
+			Background: w.Background,
+			Image:      w.Image,
+			Gradient:   w.Gradient,
+			Tile:       w.Tile,
+			NineSlice:  w.NineSlice,
+		}
+		bg := &w.backgroundShape
+		bg.loadDrawCommand(&DrawCommand{Type: ShapeRectangle, Props: &w.backgroundProps})
+		bg.DrawBatched(b)
 	}
+
+	w.drawParallax(b)
+}
+
+func (w *World) SetCamera(x, y float64) {
+	w.Camera = Vector2{X: x, Y: y}
+}
+
+func (w *World) MoveCamera(dx, dy float64) {
+	w.Camera = w.Camera.Add(Vector2{X: dx, Y: dy})
+}
+
+func (w *World) ScreenToWorld(x, y float64) Vector2 {
+	return Vector2{X: x + w.Camera.X, Y: y + w.Camera.Y}
 }
 
 func (w *World) LoadSound(name string, fs embed.FS, filePath string) error {
@@ -1027,8 +1355,7 @@ func (w *World) HoveredObjects() []*Shape {
 
 	var hovered []*Shape
 	for _, obj := range w.Objects {
-		if w.Mouse.X >= obj.X && w.Mouse.X <= obj.X+obj.Width &&
-			w.Mouse.Y >= obj.Y && w.Mouse.Y <= obj.Y+obj.Height {
+		if w.isUnderMouse(obj) {
 			hovered = append(hovered, obj)
 		}
 	}
@@ -1041,14 +1368,27 @@ func (w *World) UnhoveredObjects() []*Shape {
 
 	var unhovered []*Shape
 	for _, obj := range w.Objects {
-		if !(w.Mouse.X >= obj.X && w.Mouse.X <= obj.X+obj.Width &&
-			w.Mouse.Y >= obj.Y && w.Mouse.Y <= obj.Y+obj.Height) {
+		if !w.isUnderMouse(obj) {
 			unhovered = append(unhovered, obj)
 		}
 	}
 	return unhovered
 }
 
+func (w *World) isUnderMouse(obj *Shape) bool {
+	if !w.IsLayerVisible(obj.Layer) {
+		return false
+	}
+
+	mouse := Vector2{X: w.Mouse.X, Y: w.Mouse.Y}
+	if !w.isScreenSpace(obj.Layer) {
+		mouse = w.ScreenToWorld(mouse.X, mouse.Y)
+	}
+
+	return mouse.X >= obj.X && mouse.X <= obj.X+obj.Width &&
+		mouse.Y >= obj.Y && mouse.Y <= obj.Y+obj.Height
+}
+
 func (w *World) GetAllElements() []*Shape {
 	w.mutex.RLock()
 	defer w.mutex.RUnlock()
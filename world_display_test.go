@@ -0,0 +1,90 @@
+//go:build display
+
+package life
+
+import (
+	"image/color"
+	"os"
+	"testing"
+
+	"github.com/hajimehoshi/ebiten/v2"
+)
+
+type testGame struct {
+	m    *testing.M
+	code int
+}
+
+func (g *testGame) Update() error {
+	g.code = g.m.Run()
+	return ebiten.Termination
+}
+
+func (g *testGame) Draw(screen *ebiten.Image) {}
+
+func (g *testGame) Layout(outsideWidth, outsideHeight int) (int, int) {
+	return outsideWidth, outsideHeight
+}
+
+// Drawing needs a running game loop, so these run only with -tags display on a
+// machine with a window.
+func TestMain(m *testing.M) {
+	game := &testGame{m: m}
+	if err := ebiten.RunGame(game); err != nil {
+		panic(err)
+	}
+	os.Exit(game.code)
+}
+
+func BenchmarkWorldDraw5000(b *testing.B) {
+	w := NewWorld(&WorldProps{Width: 800, Height: 600})
+	for i := 0; i < 5000; i++ {
+		props := &ShapeProps{
+			X:          float64(i%100) * 8,
+			Y:          float64(i/100) * 12,
+			Width:      6,
+			Height:     6,
+			Background: color.RGBA{uint8(i), 120, 200, 255},
+		}
+		switch i % 4 {
+		case 1:
+			props.Type = ShapeCircle
+			props.Radius = 3
+		case 2:
+			props.Rotation = float64(i)
+		case 3:
+			props.CornerRadius = 2
+		}
+		w.Register(NewShape(props))
+	}
+
+	screen := ebiten.NewImage(800, 600)
+	w.Draw(screen)
+	w.Draw(screen)
+	drawCalls := w.batch.DrawCalls
+
+	// ebiten allocates inside Fill and DrawTriangles on its own, so measure that
+	// once and only hold the engine to zero allocations on top of it.
+	var reference Batch
+	var geoM ebiten.GeoM
+	fill := testing.AllocsPerRun(100, func() {
+		screen.Fill(w.Background)
+	})
+	call := testing.AllocsPerRun(100, func() {
+		reference.Begin(screen)
+		reference.FillRect(&geoM, 1, 1, color.White, 1)
+		reference.End()
+	})
+	budget := fill + call*float64(drawCalls)
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		w.Draw(screen)
+	}
+	b.StopTimer()
+
+	if allocs := testing.AllocsPerRun(20, func() { w.Draw(screen) }); allocs > budget {
+		b.Fatalf("World.Draw allocated %.0f times per frame with 5000 shapes and %d draw calls, want at most %.0f from ebiten itself", allocs, drawCalls, budget)
+	}
+}